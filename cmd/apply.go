@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+	"github.com/kuadrant/kuadrant-operator/pkg/library/kuadrant"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+const (
+	applyDryRunNone   = "none"
+	applyDryRunClient = "client"
+	applyDryRunServer = "server"
+)
+
+var (
+	applyOAS            []string
+	applyNamespace      string
+	applyDryRun         string
+	applyFieldManager   string
+	applyForceConflicts bool
+	applyWait           bool
+	applyTimeout        time.Duration
+)
+
+// applyWaitInterval is the period of the status checks of the policies
+var applyWaitInterval = 2 * time.Second
+
+//kuadrantctl apply --oas [OAS_FILE_PATH | OAS_DIR | OAS_GLOB | OAS_URL | @]... [--dry-run=client|server] [--wait]
+
+func applyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply the Gateway API HTTPRoute and the Kuadrant policies generated from OpenAPI 3.0.X or 3.1.X to the cluster",
+		Long: `Apply the Gateway API HTTPRoute, Kuadrant AuthPolicy and Kuadrant RateLimitPolicy generated from OpenAPI 3.0.X or 3.1.X to the cluster.
+The resources are the ones generated by the 'generate kuadrant bundle' command, server-side applied with a dedicated field manager.
+The cluster is the one of the current kubeconfig context.`,
+		RunE: runApply,
+	}
+
+	cmd.Flags().StringArrayVar(&applyOAS, "oas", nil, "Path to OpenAPI spec file (in JSON or YAML format), directory, glob pattern, URL, or '-' to read from standard input. Can be repeated to apply the resources of several documents (required)")
+	addOASReadFlags(cmd)
+	cmd.Flags().StringVarP(&applyNamespace, "namespace", "n", "", "Namespace of the resources without namespace (default the namespace of the current kubeconfig context)")
+	cmd.Flags().StringVar(&applyDryRun, "dry-run", applyDryRunNone, "Only print the resources to apply: 'none', 'client' (without contacting the cluster) or 'server' (validated by the cluster without being persisted)")
+	cmd.Flags().StringVar(&applyFieldManager, "field-manager", "kuadrantctl", "Name of the field manager owning the applied fields")
+	cmd.Flags().BoolVar(&applyForceConflicts, "force-conflicts", false, "Take the ownership of the fields managed by other field managers with different values")
+	cmd.Flags().BoolVar(&applyWait, "wait", false, "Wait for the policies to be Accepted and Enforced")
+	cmd.Flags().DurationVar(&applyTimeout, "timeout", 2*time.Minute, "Maximum time to wait for the policies with --wait")
+	addGenerateOptionsFlags(cmd)
+	err := cmd.MarkFlagRequired("oas")
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// applyResult is the outcome of applying a resource
+type applyResult struct {
+	Object client.Object
+	// Generation of the applied object, returned by the server
+	Generation int64
+	Message    string
+	Err        error
+}
+
+func (r applyResult) String() string {
+	line := fmt.Sprintf("%s %s", r.Object.GetObjectKind().GroupVersionKind().Kind, objectKeyString(r.Object))
+	if r.Err != nil {
+		return fmt.Sprintf("%s failed: %v", line, r.Err)
+	}
+	return fmt.Sprintf("%s %s", line, r.Message)
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	switch applyDryRun {
+	case applyDryRunNone, applyDryRunClient, applyDryRunServer:
+	default:
+		return fmt.Errorf("unknown value %q for --dry-run, valid values: [%s %s %s]", applyDryRun, applyDryRunNone, applyDryRunClient, applyDryRunServer)
+	}
+
+	if applyWait && applyDryRun != applyDryRunNone {
+		return errors.New("--wait cannot be used with --dry-run")
+	}
+
+	// the namespaces are defaulted before the conflicts between the resources of several documents are checked
+	defaultNamespace := sync.OnceValues(applyDefaultNamespace)
+	objects, err := generateObjects(cmd, applyOAS, func(doc *openapi3.T, opts *utils.GenerateOptions) ([]client.Object, error) {
+		objects, err := buildBundle(doc, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range objects {
+			if obj.GetNamespace() != "" {
+				continue
+			}
+
+			namespace, err := defaultNamespace()
+			if err != nil {
+				return nil, err
+			}
+			obj.SetNamespace(namespace)
+		}
+
+		return objects, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range objects {
+		if obj.GetName() == "" {
+			return fmt.Errorf("%s without name cannot be applied, set the route name of the kuadrant extension", obj.GetObjectKind().GroupVersionKind().Kind)
+		}
+	}
+
+	var results []applyResult
+	if applyDryRun == applyDryRunClient {
+		for _, obj := range objects {
+			results = append(results, applyResult{Object: obj, Message: "serverside-applied (dry run)"})
+		}
+		return reportApplyResults(cmd, results)
+	}
+
+	k8sClient, err := applyClient()
+	if err != nil {
+		return err
+	}
+
+	results = applyObjects(cmd.Context(), k8sClient, objects, applyDryRun == applyDryRunServer)
+	if err := reportApplyResults(cmd, results); err != nil || !applyWait {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), applyTimeout)
+	defer cancel()
+
+	return reportApplyResults(cmd, waitForPolicies(ctx, k8sClient, results))
+}
+
+// applyDefaultNamespace returns the --namespace flag value,
+// or the namespace of the current kubeconfig context, default when there is no kubeconfig
+func applyDefaultNamespace() (string, error) {
+	if applyNamespace != "" {
+		return applyNamespace, nil
+	}
+
+	namespace, _, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{},
+	).Namespace()
+	if clientcmd.IsEmptyConfig(err) {
+		return metav1.NamespaceDefault, nil
+	}
+	return namespace, err
+}
+
+// applyClient returns the client of the cluster of the current kubeconfig context,
+// knowing the Gateway API and Kuadrant types
+func applyClient() (client.Client, error) {
+	configuration, err := config.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.New(configuration, client.Options{Scheme: applyScheme()})
+}
+
+func applyScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(s))
+	utilruntime.Must(gatewayapiv1.AddToScheme(s))
+	utilruntime.Must(gatewayapiv1alpha2.AddToScheme(s))
+	utilruntime.Must(kuadrantapiv1beta2.AddToScheme(s))
+	return s
+}
+
+// applyObjects server-side applies the objects in order, the failures do not stop the next objects
+func applyObjects(ctx context.Context, k8sClient client.Client, objects []client.Object, dryRun bool) []applyResult {
+	opts := []client.PatchOption{client.FieldOwner(applyFieldManager)}
+	if applyForceConflicts {
+		opts = append(opts, client.ForceOwnership)
+	}
+	message := "serverside-applied"
+	if dryRun {
+		opts = append(opts, client.DryRunAll)
+		message = "serverside-applied (server dry run)"
+	}
+
+	results := make([]applyResult, 0, len(objects))
+	for _, obj := range objects {
+		// the patch response replaces the object, the generated one is kept for the report
+		applied := obj.DeepCopyObject().(client.Object)
+		err := k8sClient.Patch(ctx, applied, client.Apply, opts...)
+		logf.Log.V(1).Info("Applied resource", "object", client.ObjectKeyFromObject(obj), "generation", applied.GetGeneration(), "error", err)
+		results = append(results, applyResult{Object: obj, Generation: applied.GetGeneration(), Message: message, Err: err})
+	}
+
+	return results
+}
+
+// waitForPolicies waits for the applied AuthPolicies and RateLimitPolicies to be Accepted and Enforced
+// in the applied generation, until the context is done. The policies failing to apply are skipped.
+func waitForPolicies(ctx context.Context, k8sClient client.Reader, applied []applyResult) []applyResult {
+	var results []applyResult
+
+	for _, appliedResult := range applied {
+		obj := appliedResult.Object
+		if _, _, isPolicy := policyStatus(obj); !isPolicy || appliedResult.Err != nil {
+			continue
+		}
+
+		var pending *metav1.Condition
+		var getErr error
+		err := wait.PollUntilContextCancel(ctx, applyWaitInterval, true, func(ctx context.Context) (bool, error) {
+			current := obj.DeepCopyObject().(client.Object)
+			// errors reading the policy are retried until the timeout
+			if getErr = k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), current); getErr != nil {
+				return false, nil
+			}
+
+			conditions, observedGeneration, _ := policyStatus(current)
+			pending = pendingPolicyCondition(conditions, observedGeneration, appliedResult.Generation)
+			return pending == nil, nil
+		})
+
+		result := applyResult{Object: obj, Message: "accepted and enforced"}
+		switch {
+		case err == nil:
+		case getErr != nil:
+			result.Err = fmt.Errorf("waiting for the policy: %w", getErr)
+		case pending != nil:
+			result.Err = fmt.Errorf("waiting for the policy: condition %s is %s: %s: %s", pending.Type, pending.Status, pending.Reason, pending.Message)
+		default:
+			result.Err = fmt.Errorf("waiting for the policy: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// policyStatus returns the status conditions and the observed generation of the Kuadrant policies
+func policyStatus(obj client.Object) ([]metav1.Condition, int64, bool) {
+	switch policy := obj.(type) {
+	case *kuadrantapiv1beta2.AuthPolicy:
+		return policy.Status.Conditions, policy.Status.ObservedGeneration, true
+	case *kuadrantapiv1beta2.RateLimitPolicy:
+		return policy.Status.Conditions, policy.Status.ObservedGeneration, true
+	}
+
+	return nil, 0, false
+}
+
+// pendingPolicyCondition returns the first of the Accepted and Enforced conditions not true, nil when both are true.
+// The conditions of a status observing a generation older than the applied one are pending.
+func pendingPolicyCondition(conditions []metav1.Condition, observedGeneration, generation int64) *metav1.Condition {
+	if observedGeneration < generation {
+		return &metav1.Condition{
+			Type: string(gatewayapiv1alpha2.PolicyConditionAccepted), Status: metav1.ConditionUnknown, Reason: "Outdated",
+			Message: fmt.Sprintf("status of generation %d, the applied generation %d not observed yet", observedGeneration, generation),
+		}
+	}
+
+	for _, conditionType := range []string{string(gatewayapiv1alpha2.PolicyConditionAccepted), string(kuadrant.PolicyConditionEnforced)} {
+		condition := meta.FindStatusCondition(conditions, conditionType)
+		if condition == nil {
+			return &metav1.Condition{Type: conditionType, Status: metav1.ConditionUnknown, Reason: "Missing", Message: "condition not reported yet"}
+		}
+		if condition.Status != metav1.ConditionTrue {
+			return condition
+		}
+	}
+
+	return nil
+}
+
+// reportApplyResults prints a line per result, and returns an error when any result failed
+func reportApplyResults(cmd *cobra.Command, results []applyResult) error {
+	var failures []string
+	for _, result := range results {
+		fmt.Fprintln(cmd.OutOrStdout(), result.String())
+		if result.Err != nil {
+			failures = append(failures, fmt.Sprintf("%s %s", result.Object.GetObjectKind().GroupVersionKind().Kind, objectKeyString(result.Object)))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d resources failed: %s", len(failures), len(results), strings.Join(failures, ", "))
+	}
+
+	return nil
+}
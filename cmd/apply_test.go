@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Apply", func() {
+	var (
+		cmd             *cobra.Command
+		cmdStdoutBuffer *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		cmd = applyCommand()
+		cmdStdoutBuffer = bytes.NewBufferString("")
+		cmd.SetOut(cmdStdoutBuffer)
+		cmd.SetErr(bytes.NewBufferString(""))
+	})
+
+	It("client dry run reports the resources without contacting the cluster", func() {
+		cmd.SetArgs([]string{"--oas", "testdata/batch", "--dry-run=client"})
+		Expect(cmd.Execute()).To(Succeed())
+		Expect(strings.Split(strings.TrimSpace(cmdStdoutBuffer.String()), "\n")).To(Equal([]string{
+			"HTTPRoute pets/cats serverside-applied (dry run)",
+			"RateLimitPolicy pets/cats serverside-applied (dry run)",
+			"HTTPRoute pets/dogs serverside-applied (dry run)",
+			"RateLimitPolicy pets/dogs serverside-applied (dry run)",
+		}))
+	})
+
+	It("resources without namespace are applied in the namespace flag value", func() {
+		cmd.SetArgs([]string{"--oas", "testdata/petstore_route_without_namespace.yaml", "--dry-run=client", "-n", "apis"})
+		Expect(cmd.Execute()).To(Succeed())
+		Expect(strings.Split(strings.TrimSpace(cmdStdoutBuffer.String()), "\n")).To(Equal([]string{
+			"HTTPRoute apis/cats serverside-applied (dry run)",
+			"RateLimitPolicy apis/cats serverside-applied (dry run)",
+		}))
+	})
+
+	It("resources defaulted to the namespace of other documents resources are reported as conflicts", func() {
+		cmd.SetArgs([]string{
+			"--oas", "testdata/petstore_route_apis_namespace.yaml", "--oas", "testdata/petstore_route_without_namespace.yaml",
+			"--dry-run=client", "-n", "apis",
+		})
+		Expect(cmd.Execute()).To(MatchError(ContainSubstring(
+			"testdata/petstore_route_without_namespace.yaml#: HTTPRoute apis/cats is also generated from testdata/petstore_route_apis_namespace.yaml",
+		)))
+	})
+
+	It("resources without name are rejected", func() {
+		data, err := os.ReadFile("testdata/petstore_openapi.yaml")
+		Expect(err).ShouldNot(HaveOccurred())
+		// without root kuadrant extension, the resources have no name
+		withoutRoute := regexp.MustCompile(`(?s)\nx-kuadrant:.*?\nservers:`).ReplaceAll(data, []byte("\nservers:"))
+		file := filepath.Join(GinkgoT().TempDir(), "petstore.yaml")
+		Expect(os.WriteFile(file, withoutRoute, 0o600)).To(Succeed())
+
+		cmd.SetArgs([]string{"--oas", file, "--dry-run=client"})
+		Expect(cmd.Execute()).To(MatchError("HTTPRoute without name cannot be applied, set the route name of the kuadrant extension"))
+	})
+
+	DescribeTable("invalid flags",
+		func(args []string, expected string) {
+			cmd.SetArgs(append([]string{"--oas", "testdata/petstore_openapi.yaml"}, args...))
+			Expect(cmd.Execute()).To(MatchError(expected))
+		},
+		Entry("unknown dry run", []string{"--dry-run=all"}, `unknown value "all" for --dry-run, valid values: [none client server]`),
+		Entry("wait with dry run", []string{"--dry-run=server", "--wait"}, "--wait cannot be used with --dry-run"),
+	)
+})
+
+var _ = Describe("waitForPolicies", func() {
+	policyStatus := func(accepted, enforced metav1.ConditionStatus) []metav1.Condition {
+		return []metav1.Condition{
+			{Type: "Accepted", Status: accepted, Reason: "Accepted", LastTransitionTime: metav1.Now()},
+			{Type: "Enforced", Status: enforced, Reason: "Unknown", Message: "policy not enforced yet", LastTransitionTime: metav1.Now()},
+		}
+	}
+
+	BeforeEach(func() {
+		DeferCleanup(func(interval time.Duration) { applyWaitInterval = interval }, applyWaitInterval)
+		applyWaitInterval = 10 * time.Millisecond
+	})
+
+	It("reports the policies not accepted or enforced in the applied generation before the timeout", func() {
+		authPolicy := func(name string) *kuadrantapiv1beta2.AuthPolicy {
+			return &kuadrantapiv1beta2.AuthPolicy{
+				TypeMeta:   metav1.TypeMeta{APIVersion: kuadrantapiv1beta2.GroupVersion.String(), Kind: "AuthPolicy"},
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "apis"},
+			}
+		}
+		ap := authPolicy("petstore")
+		outdatedAP := authPolicy("outdated")
+		failedAP := authPolicy("failed")
+		rlp := &kuadrantapiv1beta2.RateLimitPolicy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: kuadrantapiv1beta2.GroupVersion.String(), Kind: "RateLimitPolicy"},
+			ObjectMeta: metav1.ObjectMeta{Name: "petstore", Namespace: "apis"},
+		}
+
+		clusterAP := ap.DeepCopy()
+		clusterAP.Status.Conditions = policyStatus(metav1.ConditionTrue, metav1.ConditionTrue)
+		clusterAP.Status.ObservedGeneration = 1
+		// the status of the previous generation
+		clusterOutdatedAP := outdatedAP.DeepCopy()
+		clusterOutdatedAP.Status.Conditions = policyStatus(metav1.ConditionTrue, metav1.ConditionTrue)
+		clusterOutdatedAP.Status.ObservedGeneration = 1
+		clusterRLP := rlp.DeepCopy()
+		clusterRLP.Status.Conditions = policyStatus(metav1.ConditionTrue, metav1.ConditionFalse)
+		clusterRLP.Status.ObservedGeneration = 1
+		k8sClient := fake.NewClientBuilder().WithScheme(applyScheme()).WithObjects(clusterAP, clusterOutdatedAP, clusterRLP).Build()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		results := waitForPolicies(ctx, k8sClient, []applyResult{
+			{Object: ap, Generation: 1},
+			{Object: outdatedAP, Generation: 2},
+			{Object: failedAP, Err: errors.New("conflict")},
+			{Object: rlp, Generation: 1},
+		})
+		Expect(results).To(HaveLen(3))
+		Expect(results[0].String()).To(Equal("AuthPolicy apis/petstore accepted and enforced"))
+		Expect(results[1].String()).To(Equal("AuthPolicy apis/outdated failed: waiting for the policy: condition Accepted is Unknown: Outdated: status of generation 1, the applied generation 2 not observed yet"))
+		Expect(results[2].String()).To(Equal("RateLimitPolicy apis/petstore failed: waiting for the policy: condition Enforced is False: Unknown: policy not enforced yet"))
+	})
+})
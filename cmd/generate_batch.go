@@ -0,0 +1,590 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+// oasSpec is an OpenAPI document read from the --oas flag values
+type oasSpec struct {
+	// Source names the document in the problems: the resource it is read from,
+	// followed by the position of the document in multi-document standard input
+	Source    string
+	Doc       *openapi3.T
+	SpecOrder *utils.SpecOrder
+}
+
+// generateObjects builds the objects of every OpenAPI document read from the --oas flag values.
+// With several documents, the problems are located by the source of the document,
+// reported for every document at once, and the objects generated from different documents
+// are checked for conflicts.
+func generateObjects(cmd *cobra.Command, resources []string, build func(*openapi3.T, *utils.GenerateOptions) ([]client.Object, error)) ([]client.Object, error) {
+	specs, problems, err := loadOpenAPISpecs(cmd, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(specs) == 1 && len(problems) == 0 {
+		opts, err := generateOptions(cmd, specs[0].SpecOrder)
+		if err != nil {
+			return nil, err
+		}
+
+		return build(specs[0].Doc, opts)
+	}
+
+	var objects []client.Object
+	var generated []generatedObject
+	// conflicts are already located by the source, their warnings skip the source of the document handlers
+	var warn func(utils.Problem)
+
+	for _, spec := range specs {
+		opts, err := generateOptions(cmd, spec.SpecOrder)
+		if err != nil {
+			return nil, err
+		}
+
+		warningHandler := opts.WarningHandler
+		warn = warningHandler
+		source := spec.Source
+		opts.WarningHandler = func(problem utils.Problem) {
+			warningHandler(sourceProblem(source, problem))
+		}
+
+		specObjects, err := build(spec.Doc, opts)
+		problems.Append(sourceProblems(spec.Source, err))
+
+		objects = append(objects, specObjects...)
+		for _, obj := range specObjects {
+			generated = append(generated, generatedObject{Source: spec.Source, Object: obj})
+		}
+	}
+
+	if err := problems.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
+	conflicts := generatedObjectConflicts(generated)
+	if err := conflicts.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
+	for _, problem := range conflicts {
+		warn(problem)
+	}
+
+	return objects, nil
+}
+
+// oasDocument is the raw OpenAPI document read from the source
+type oasDocument struct {
+	Source   string
+	Data     []byte
+	Location *url.URL
+	Reader   *utils.ExternalResourceReader
+}
+
+// loadOpenAPISpecs reads, parses and validates the OpenAPI documents of the --oas flag values.
+// Directories and glob patterns are expanded, standard input may hold several YAML documents.
+// The problems of a single document are returned as error. The problems of several documents
+// are returned along with the documents loaded, located by the source of the document.
+func loadOpenAPISpecs(cmd *cobra.Command, resources []string) ([]*oasSpec, utils.Problems, error) {
+	resources, err := expandOASResources(resources)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var problems utils.Problems
+	var documents []oasDocument
+
+	for _, resource := range resources {
+		resourceDocuments, err := readOASResource(cmd, resource)
+		if err != nil {
+			if len(resources) == 1 {
+				return nil, nil, err
+			}
+			problems.Append(sourceProblems(resource, err))
+			continue
+		}
+		documents = append(documents, resourceDocuments...)
+	}
+
+	if len(documents) == 1 && len(problems) == 0 {
+		doc, specOrder, err := loadOpenAPIData(documents[0].Data, documents[0].Location, documents[0].Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []*oasSpec{{Source: documents[0].Source, Doc: doc, SpecOrder: specOrder}}, nil, nil
+	}
+
+	specs := make([]*oasSpec, 0, len(documents))
+	for _, document := range documents {
+		doc, specOrder, err := loadOpenAPIData(document.Data, document.Location, document.Reader)
+		if err != nil {
+			problems.Append(sourceProblems(document.Source, err))
+			continue
+		}
+		specs = append(specs, &oasSpec{Source: document.Source, Doc: doc, SpecOrder: specOrder})
+	}
+
+	return specs, problems, nil
+}
+
+// readOASResource reads the OpenAPI documents of the resource.
+// Standard input may hold several YAML documents, sourced by their position in the stream.
+func readOASResource(cmd *cobra.Command, resource string) ([]oasDocument, error) {
+	reader := oasResourceReader(cmd, resource)
+
+	oasDataRaw, err := reader.Read(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := reader.Location(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	if resource != "-" && resource != "@" {
+		return []oasDocument{{Source: resource, Data: oasDataRaw, Location: location, Reader: reader}}, nil
+	}
+
+	rawDocuments := splitYAMLDocuments(oasDataRaw)
+	documents := make([]oasDocument, 0, len(rawDocuments))
+	for idx, oasData := range rawDocuments {
+		source := resource
+		if len(rawDocuments) > 1 {
+			source = fmt.Sprintf("%s[%d]", resource, idx)
+		}
+		documents = append(documents, oasDocument{Source: source, Data: oasData, Location: location, Reader: reader})
+	}
+
+	return documents, nil
+}
+
+// expandOASResources replaces the directories by their JSON and YAML files, and the glob patterns
+// by the files they match. Other resources are kept. Duplicates are removed.
+func expandOASResources(resources []string) ([]string, error) {
+	var expanded []string
+	seen := map[string]bool{}
+	add := func(resource string) {
+		if !seen[resource] {
+			seen[resource] = true
+			expanded = append(expanded, resource)
+		}
+	}
+
+	for _, resource := range resources {
+		if resource == "-" || resource == "@" || strings.Contains(resource, "://") {
+			add(resource)
+			continue
+		}
+
+		if info, err := os.Stat(resource); err == nil {
+			if !info.IsDir() {
+				add(resource)
+				continue
+			}
+
+			files, err := oasFilesInDir(resource)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range files {
+				add(file)
+			}
+			continue
+		}
+
+		if !strings.ContainsAny(resource, "*?[") {
+			add(resource)
+			continue
+		}
+
+		matches, err := filepath.Glob(resource)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %w", resource, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no OpenAPI document matches %q", resource)
+		}
+		for _, match := range matches {
+			add(match)
+		}
+	}
+
+	return expanded, nil
+}
+
+// oasFilesInDir returns the sorted JSON and YAML files of the directory, subdirectories excluded
+func oasFilesInDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".yaml", ".yml":
+			if !entry.IsDir() {
+				files = append(files, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no OpenAPI document (.json, .yaml or .yml file) found in directory %s", dir)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// splitYAMLDocuments splits a YAML stream at the '---' document separators.
+// Empty documents are skipped.
+func splitYAMLDocuments(data []byte) [][]byte {
+	var documents [][]byte
+	var current bytes.Buffer
+
+	flush := func() {
+		if len(bytes.TrimSpace(current.Bytes())) > 0 {
+			documents = append(documents, bytes.Clone(current.Bytes()))
+		}
+		current.Reset()
+	}
+
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if separator := bytes.TrimRight(line, " \t\r\n"); string(separator) == "---" {
+			flush()
+			continue
+		}
+		current.Write(line)
+	}
+	flush()
+
+	if len(documents) == 0 {
+		return [][]byte{data}
+	}
+
+	return documents
+}
+
+// sourceProblem locates the problem in the document read from the source
+func sourceProblem(source string, problem utils.Problem) utils.Problem {
+	problem.Pointer = source + problem.Pointer
+	return problem
+}
+
+// sourceProblems returns the problems carried by err located in the document read from the source
+func sourceProblems(source string, err error) utils.Problems {
+	var problems, located utils.Problems
+	problems.Append(err)
+	for _, problem := range problems {
+		located = append(located, sourceProblem(source, problem))
+	}
+	return located
+}
+
+// generatedObject is an object generated from the OpenAPI document read from the source
+type generatedObject struct {
+	Source string
+	Object client.Object
+}
+
+// generatedObjectConflicts returns the conflicts between the objects generated from different documents:
+// objects of the same kind and name in the same namespace, HTTPRoutes of the same Gateways with overlapping
+// hostnames and matches, and RateLimitPolicies of the same namespace with limits of the same name.
+// The problems are located at the document generating the last object of the conflict.
+func generatedObjectConflicts(generated []generatedObject) utils.Problems {
+	var problems utils.Problems
+
+	for idx, later := range generated {
+		for _, earlier := range generated[:idx] {
+			if earlier.Source == later.Source {
+				continue
+			}
+
+			if problem, ok := duplicateObjectConflict(earlier, later); ok {
+				problems.Add(problem)
+				continue
+			}
+
+			switch laterObj := later.Object.(type) {
+			case *gatewayapiv1.HTTPRoute:
+				if earlierObj, ok := earlier.Object.(*gatewayapiv1.HTTPRoute); ok {
+					if problem, ok := httpRouteConflict(earlier.Source, earlierObj, later.Source, laterObj); ok {
+						problems.Add(problem)
+					}
+				}
+			case *kuadrantapiv1beta2.RateLimitPolicy:
+				if earlierObj, ok := earlier.Object.(*kuadrantapiv1beta2.RateLimitPolicy); ok {
+					problems.Add(rateLimitPolicyConflicts(earlier.Source, earlierObj, later.Source, laterObj)...)
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+func duplicateObjectConflict(earlier, later generatedObject) (utils.Problem, bool) {
+	earlierKind := earlier.Object.GetObjectKind().GroupVersionKind().Kind
+	laterKind := later.Object.GetObjectKind().GroupVersionKind().Kind
+
+	if earlierKind != laterKind || earlier.Object.GetNamespace() != later.Object.GetNamespace() || earlier.Object.GetName() != later.Object.GetName() {
+		return utils.Problem{}, false
+	}
+
+	return utils.NewError(later.Source+utils.JSONPointer(),
+		"%s %s is also generated from %s", laterKind, objectKeyString(later.Object), earlier.Source,
+	), true
+}
+
+// httpRouteConflict returns a problem when the HTTPRoutes share a Gateway and have overlapping hostnames and matches.
+// Only the first overlapping match is reported. Matches that may overlap, depending on regular expressions
+// that cannot be compared, are reported as a warning.
+func httpRouteConflict(earlierSource string, earlier *gatewayapiv1.HTTPRoute, laterSource string, later *gatewayapiv1.HTTPRoute) (utils.Problem, bool) {
+	if !httpRoutesShareParent(earlier, later) || !hostnamesOverlap(earlier.Spec.Hostnames, later.Spec.Hostnames) {
+		return utils.Problem{}, false
+	}
+
+	var warning utils.Problem
+	var mayOverlap bool
+	for _, laterRule := range later.Spec.Rules {
+		for _, laterMatch := range laterRule.Matches {
+			for _, earlierRule := range earlier.Spec.Rules {
+				for _, earlierMatch := range earlierRule.Matches {
+					switch httpRouteMatchesOverlap(earlierMatch, laterMatch) {
+					case matchesOverlap:
+						return utils.NewError(laterSource+utils.JSONPointer(),
+							"HTTPRoute %s match %s overlaps with the match %s of HTTPRoute %s generated from %s",
+							objectKeyString(later), httpRouteMatchString(laterMatch),
+							httpRouteMatchString(earlierMatch), objectKeyString(earlier), earlierSource,
+						), true
+					case matchesMayOverlap:
+						if !mayOverlap {
+							mayOverlap = true
+							warning = utils.NewWarning(laterSource+utils.JSONPointer(),
+								"HTTPRoute %s match %s may overlap with the match %s of HTTPRoute %s generated from %s",
+								objectKeyString(later), httpRouteMatchString(laterMatch),
+								httpRouteMatchString(earlierMatch), objectKeyString(earlier), earlierSource,
+							)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return warning, mayOverlap
+}
+
+// rateLimitPolicyConflicts returns a problem per limit name defined by both RateLimitPolicies of the same namespace
+func rateLimitPolicyConflicts(earlierSource string, earlier *kuadrantapiv1beta2.RateLimitPolicy, laterSource string, later *kuadrantapiv1beta2.RateLimitPolicy) []utils.Problem {
+	if earlier.Namespace != later.Namespace {
+		return nil
+	}
+
+	var problems []utils.Problem
+	names := make([]string, 0, len(later.Spec.Limits))
+	for name := range later.Spec.Limits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := earlier.Spec.Limits[name]; ok {
+			problems = append(problems, utils.NewError(laterSource+utils.JSONPointer(),
+				"rate limit %q of RateLimitPolicy %s is also defined by RateLimitPolicy %s generated from %s",
+				name, objectKeyString(later), objectKeyString(earlier), earlierSource,
+			))
+		}
+	}
+
+	return problems
+}
+
+func httpRoutesShareParent(earlier, later *gatewayapiv1.HTTPRoute) bool {
+	for _, earlierRef := range earlier.Spec.ParentRefs {
+		for _, laterRef := range later.Spec.ParentRefs {
+			if earlierRef.Name != laterRef.Name ||
+				parentRefNamespace(earlier, earlierRef) != parentRefNamespace(later, laterRef) {
+				continue
+			}
+
+			if earlierRef.SectionName == nil || laterRef.SectionName == nil || *earlierRef.SectionName == *laterRef.SectionName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func parentRefNamespace(route *gatewayapiv1.HTTPRoute, ref gatewayapiv1.ParentReference) string {
+	if ref.Namespace != nil {
+		return string(*ref.Namespace)
+	}
+	return route.Namespace
+}
+
+// hostnamesOverlap returns true when a request may match hostnames of both lists.
+// Routes without hostnames match every hostname.
+func hostnamesOverlap(earlier, later []gatewayapiv1.Hostname) bool {
+	if len(earlier) == 0 || len(later) == 0 {
+		return true
+	}
+
+	for _, earlierHostname := range earlier {
+		for _, laterHostname := range later {
+			if hostnameCovers(string(earlierHostname), string(laterHostname)) || hostnameCovers(string(laterHostname), string(earlierHostname)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// hostnameCovers returns true when the hostname, possibly a wildcard, matches the other one
+func hostnameCovers(hostname, other string) bool {
+	if hostname == other {
+		return true
+	}
+
+	suffix, isWildcard := strings.CutPrefix(hostname, "*")
+	return isWildcard && strings.HasSuffix(other, suffix)
+}
+
+// matchOverlap tells whether a request may match two HTTPRoute matches
+type matchOverlap int
+
+const (
+	matchesDisjoint matchOverlap = iota
+	matchesMayOverlap
+	matchesOverlap
+)
+
+// httpRouteMatchesOverlap tells whether a request may match both matches.
+// Header and query parameter matches are not compared.
+func httpRouteMatchesOverlap(earlier, later gatewayapiv1.HTTPRouteMatch) matchOverlap {
+	if earlier.Method != nil && later.Method != nil && *earlier.Method != *later.Method {
+		return matchesDisjoint
+	}
+
+	earlierType, earlierValue := pathMatchTypeValue(earlier.Path)
+	laterType, laterValue := pathMatchTypeValue(later.Path)
+
+	var covered bool
+	switch {
+	case earlierType == gatewayapiv1.PathMatchRegularExpression:
+		return regexMatchOverlap(earlierValue, laterType, laterValue)
+	case laterType == gatewayapiv1.PathMatchRegularExpression:
+		return regexMatchOverlap(laterValue, earlierType, earlierValue)
+	case earlierType == gatewayapiv1.PathMatchExact && laterType == gatewayapiv1.PathMatchExact:
+		covered = earlierValue == laterValue
+	case earlierType == gatewayapiv1.PathMatchPathPrefix && laterType == gatewayapiv1.PathMatchPathPrefix:
+		covered = pathPrefixCovers(earlierValue, laterValue) || pathPrefixCovers(laterValue, earlierValue)
+	case earlierType == gatewayapiv1.PathMatchPathPrefix:
+		covered = pathPrefixCovers(earlierValue, laterValue)
+	default:
+		covered = pathPrefixCovers(laterValue, earlierValue)
+	}
+
+	if covered {
+		return matchesOverlap
+	}
+	return matchesDisjoint
+}
+
+// regexMatchOverlap tells whether a request may match the regular expression and the other path match.
+// The regular expression matches the whole path. Exact paths are tested against the regular expression,
+// otherwise the literal prefix of the regular expression tells the paths that cannot match it.
+func regexMatchOverlap(expr string, matchType gatewayapiv1.PathMatchType, value string) matchOverlap {
+	if matchType == gatewayapiv1.PathMatchRegularExpression && expr == value {
+		return matchesOverlap
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return matchesMayOverlap
+	}
+	fullMatch, err := regexp.Compile(`^(?:` + expr + `)$`)
+	if err != nil {
+		return matchesMayOverlap
+	}
+	literalPrefix, _ := re.LiteralPrefix()
+
+	switch matchType {
+	case gatewayapiv1.PathMatchExact:
+		if fullMatch.MatchString(value) {
+			return matchesOverlap
+		}
+		return matchesDisjoint
+	case gatewayapiv1.PathMatchPathPrefix:
+		prefix := strings.TrimSuffix(value, "/")
+		if prefix == "" || fullMatch.MatchString(value) || strings.HasPrefix(literalPrefix, prefix+"/") {
+			return matchesOverlap
+		}
+		if strings.HasPrefix(prefix, literalPrefix) {
+			return matchesMayOverlap
+		}
+		return matchesDisjoint
+	default:
+		other, err := regexp.Compile(value)
+		if err != nil {
+			return matchesMayOverlap
+		}
+		otherLiteralPrefix, _ := other.LiteralPrefix()
+		if strings.HasPrefix(literalPrefix, otherLiteralPrefix) || strings.HasPrefix(otherLiteralPrefix, literalPrefix) {
+			return matchesMayOverlap
+		}
+		return matchesDisjoint
+	}
+}
+
+// pathMatchTypeValue returns the type and value of the path match, defaults to PathPrefix /
+func pathMatchTypeValue(match *gatewayapiv1.HTTPPathMatch) (gatewayapiv1.PathMatchType, string) {
+	matchType, value := gatewayapiv1.PathMatchPathPrefix, "/"
+	if match != nil && match.Type != nil {
+		matchType = *match.Type
+	}
+	if match != nil && match.Value != nil {
+		value = *match.Value
+	}
+	return matchType, value
+}
+
+// pathPrefixCovers returns true when the path prefix matches the path, element-wise
+func pathPrefixCovers(prefix, path string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+func httpRouteMatchString(match gatewayapiv1.HTTPRouteMatch) string {
+	matchType, value := pathMatchTypeValue(match.Path)
+	method := "*"
+	if match.Method != nil {
+		method = string(*match.Method)
+	}
+	return fmt.Sprintf("%s %s %s", method, matchType, value)
+}
+
+func objectKeyString(obj client.Object) string {
+	return client.ObjectKeyFromObject(obj).String()
+}
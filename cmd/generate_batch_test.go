@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+var _ = Describe("Generate from several OpenAPI documents", func() {
+	var (
+		cmd             *cobra.Command
+		cmdStdoutBuffer *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		cmd = generateKuadrantBundleCommand()
+		cmdStdoutBuffer = bytes.NewBufferString("")
+		cmd.SetOut(cmdStdoutBuffer)
+		cmd.SetErr(bytes.NewBufferString(""))
+	})
+
+	generatedObjects := func() []string {
+		var names []string
+		for _, document := range splitYAMLDocuments(cmdStdoutBuffer.Bytes()) {
+			var obj metav1.PartialObjectMetadata
+			Expect(yaml.Unmarshal(document, &obj)).To(Succeed())
+			names = append(names, obj.Kind+"/"+obj.Name)
+		}
+		return names
+	}
+
+	DescribeTable("the resources of every document are generated",
+		func(args ...string) {
+			cmd.SetArgs(args)
+			Expect(cmd.Execute()).To(Succeed())
+			Expect(generatedObjects()).To(Equal([]string{
+				"HTTPRoute/cats", "RateLimitPolicy/cats", "HTTPRoute/dogs", "RateLimitPolicy/dogs",
+			}))
+		},
+		Entry("repeated flag", "--oas", "testdata/batch/cats.yaml", "--oas", "testdata/batch/dogs.yaml"),
+		Entry("directory", "--oas", "testdata/batch"),
+		Entry("glob pattern", "--oas", "testdata/batch/*.yaml"),
+		Entry("duplicates removed", "--oas", "testdata/batch", "--oas", "testdata/batch/dogs.yaml"),
+	)
+
+	It("problems are located by the document source", func() {
+		cmd.SetArgs([]string{"--oas", "testdata/invalid_oas.yaml", "--oas", "testdata/petstore_invalid_kuadrant_extensions.yaml"})
+		err := cmd.Execute()
+
+		var problems utils.Problems
+		Expect(errors.As(err, &problems)).To(BeTrue())
+		Expect(problems).To(ConsistOf(
+			And(HaveField("Pointer", "testdata/invalid_oas.yaml#"), HaveField("Message", ContainSubstring("OpenAPI validation error"))),
+			HaveField("Pointer", "testdata/petstore_invalid_kuadrant_extensions.yaml#/x-kuadrant/route/name"),
+			HaveField("Pointer", "testdata/petstore_invalid_kuadrant_extensions.yaml#/paths/~1cat/x-kuadrant"),
+		))
+	})
+
+	It("conflicts between documents are reported", func() {
+		cmd.SetArgs([]string{"--oas", "testdata/batch_conflicts"})
+		err := cmd.Execute()
+
+		var problems utils.Problems
+		Expect(errors.As(err, &problems)).To(BeTrue())
+		Expect(problems).To(ConsistOf(
+			utils.NewError("testdata/batch_conflicts/pets_copy.yaml#",
+				"HTTPRoute pets/pets is also generated from testdata/batch_conflicts/pets.yaml"),
+			utils.NewError("testdata/batch_conflicts/siamese_cats.yaml#",
+				"HTTPRoute pets/siamese-cats match GET Exact /cats/siamese overlaps with the match GET PathPrefix /cats of HTTPRoute pets/pets generated from testdata/batch_conflicts/pets.yaml"),
+			utils.NewError("testdata/batch_conflicts/siamese_cats.yaml#",
+				`rate limit "cats" of RateLimitPolicy pets/siamese-cats is also defined by RateLimitPolicy pets/pets generated from testdata/batch_conflicts/pets.yaml`),
+		))
+	})
+
+	It("matches that may overlap are reported as warnings", func() {
+		cmdStderrBuffer := bytes.NewBufferString("")
+		cmd.SetErr(cmdStderrBuffer)
+		cmd.SetArgs([]string{"--oas", "testdata/batch_regex"})
+		Expect(cmd.Execute()).To(Succeed())
+		Expect(cmdStderrBuffer.String()).To(Equal("Warning: testdata/batch_regex/cats.yaml#: " +
+			"HTTPRoute pets/cats match GET RegularExpression ^/cats/-?[0-9]+$ may overlap with the match GET RegularExpression ^/cats/(?:[a-z]+)$ " +
+			"of HTTPRoute pets/cat-names generated from testdata/batch_regex/cat_names.yaml\n"))
+	})
+
+	It("conflicts are not reported when the routes do not share a gateway and hostname", func() {
+		cmd = generateGatewayApiHttpRouteCommand()
+		cmd.SetOut(cmdStdoutBuffer)
+		cmd.SetErr(bytes.NewBufferString(""))
+		// same paths and gateway, different hostnames
+		cmd.SetArgs([]string{"--oas", "testdata/petstore_openapi.yaml", "--oas", "testdata/batch_conflicts/siamese_cats.yaml"})
+		Expect(cmd.Execute()).To(Succeed())
+	})
+})
+
+var _ = DescribeTable("splitYAMLDocuments",
+	func(stream string, expected []string) {
+		var documents []string
+		for _, document := range splitYAMLDocuments([]byte(stream)) {
+			documents = append(documents, string(document))
+		}
+		Expect(documents).To(Equal(expected))
+	},
+	Entry("single document", "openapi: 3.0.2\n", []string{"openapi: 3.0.2\n"}),
+	Entry("leading separator", "---\nopenapi: 3.0.2\n", []string{"openapi: 3.0.2\n"}),
+	Entry("several documents", "openapi: 3.0.2\n---\nopenapi: 3.1.0\n--- \n\n---\nswagger: \"2.0\"",
+		[]string{"openapi: 3.0.2\n", "openapi: 3.1.0\n", "swagger: \"2.0\""}),
+	Entry("JSON document", `{"openapi": "3.0.2"}`, []string{`{"openapi": "3.0.2"}`}),
+)
+
+var _ = DescribeTable("httpRouteMatchesOverlap",
+	func(earlierType gatewayapiv1.PathMatchType, earlierValue string, laterType gatewayapiv1.PathMatchType, laterValue string, expected matchOverlap) {
+		match := func(matchType gatewayapiv1.PathMatchType, value string) gatewayapiv1.HTTPRouteMatch {
+			return gatewayapiv1.HTTPRouteMatch{Path: &gatewayapiv1.HTTPPathMatch{Type: ptr.To(matchType), Value: ptr.To(value)}}
+		}
+		Expect(httpRouteMatchesOverlap(match(earlierType, earlierValue), match(laterType, laterValue))).To(Equal(expected))
+		Expect(httpRouteMatchesOverlap(match(laterType, laterValue), match(earlierType, earlierValue))).To(Equal(expected))
+	},
+	Entry("same exact paths", gatewayapiv1.PathMatchExact, "/cats", gatewayapiv1.PathMatchExact, "/cats", matchesOverlap),
+	Entry("prefix of the exact path", gatewayapiv1.PathMatchPathPrefix, "/cats", gatewayapiv1.PathMatchExact, "/cats/siamese", matchesOverlap),
+	Entry("prefix not element-wise", gatewayapiv1.PathMatchPathPrefix, "/cat", gatewayapiv1.PathMatchExact, "/cats", matchesDisjoint),
+	Entry("regex matching the exact path", gatewayapiv1.PathMatchRegularExpression, `^/cats/[0-9]+$`, gatewayapiv1.PathMatchExact, "/cats/42", matchesOverlap),
+	Entry("regex not matching the exact path", gatewayapiv1.PathMatchRegularExpression, `^/cats/[0-9]+$`, gatewayapiv1.PathMatchExact, "/cats/felix", matchesDisjoint),
+	Entry("regex matching the whole exact path only", gatewayapiv1.PathMatchRegularExpression, `/cats`, gatewayapiv1.PathMatchExact, "/cats/42", matchesDisjoint),
+	Entry("regex under the prefix", gatewayapiv1.PathMatchRegularExpression, `^/cats/[0-9]+$`, gatewayapiv1.PathMatchPathPrefix, "/cats", matchesOverlap),
+	Entry("regex matching the prefix", gatewayapiv1.PathMatchRegularExpression, `^/(cats|dogs)$`, gatewayapiv1.PathMatchPathPrefix, "/dogs", matchesOverlap),
+	Entry("regex maybe under the prefix", gatewayapiv1.PathMatchRegularExpression, `^/(cats|dogs)/[0-9]+$`, gatewayapiv1.PathMatchPathPrefix, "/dogs", matchesMayOverlap),
+	Entry("regex out of the prefix", gatewayapiv1.PathMatchRegularExpression, `^/dogs/[0-9]+$`, gatewayapiv1.PathMatchPathPrefix, "/cats", matchesDisjoint),
+	Entry("same regexes", gatewayapiv1.PathMatchRegularExpression, `^/cats/[0-9]+$`, gatewayapiv1.PathMatchRegularExpression, `^/cats/[0-9]+$`, matchesOverlap),
+	Entry("regexes of the same literal prefix", gatewayapiv1.PathMatchRegularExpression, `^/cats/[0-9]+$`, gatewayapiv1.PathMatchRegularExpression, `^/cats/[a-z]+$`, matchesMayOverlap),
+	Entry("regexes of different literal prefixes", gatewayapiv1.PathMatchRegularExpression, `^/cats/[0-9]+$`, gatewayapiv1.PathMatchRegularExpression, `^/dogs/[0-9]+$`, matchesDisjoint),
+	Entry("invalid regex", gatewayapiv1.PathMatchRegularExpression, `^/cats/(`, gatewayapiv1.PathMatchExact, "/cats", matchesMayOverlap),
+)
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var _ = Describe("Generate with compacted rules", func() {
+	// 26 operations: 9 operations routed to the same backend and 17 operations routed to their own backend
+	const oas = "testdata/petstore_many_operations.yaml"
+
+	run := func(newCommand func() *cobra.Command, args ...string) []byte {
+		cmd := newCommand()
+		cmdStdoutBuffer := bytes.NewBufferString("")
+		cmd.SetOut(cmdStdoutBuffer)
+		cmd.SetErr(bytes.NewBufferString(""))
+		cmd.SetArgs(append([]string{"--oas", oas, "-o", "json"}, args...))
+		Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+		return cmdStdoutBuffer.Bytes()
+	}
+
+	unmarshalList := func(data []byte, items ...any) {
+		var list metav1.List
+		Expect(yaml.Unmarshal(data, &list)).ShouldNot(HaveOccurred())
+		Expect(list.Items).To(HaveLen(len(items)))
+		for idx, item := range items {
+			Expect(yaml.Unmarshal(list.Items[idx].Raw, item)).ShouldNot(HaveOccurred())
+		}
+	}
+
+	It("one rule per operation by default", func() {
+		var httpRoute gatewayapiv1.HTTPRoute
+		Expect(yaml.Unmarshal(run(generateGatewayApiHttpRouteCommand), &httpRoute)).ShouldNot(HaveOccurred())
+		Expect(httpRoute.Spec.Rules).To(HaveLen(26))
+	})
+
+	It("HTTPRoute rules compacted and split", func() {
+		var first, second gatewayapiv1.HTTPRoute
+		unmarshalList(run(generateGatewayApiHttpRouteCommand, "--compact-rules"), &first, &second)
+
+		Expect(first.Name).To(Equal("petstore"))
+		Expect(second.Name).To(Equal("petstore-2"))
+		Expect(second.Namespace).To(Equal("petstore-ns"))
+		Expect(second.Spec.ParentRefs).To(Equal(first.Spec.ParentRefs))
+		Expect(second.Spec.Hostnames).To(Equal(first.Spec.Hostnames))
+
+		Expect(first.Spec.Rules).To(HaveLen(16))
+		Expect(second.Spec.Rules).To(HaveLen(3))
+
+		// operations routed to the same backend, up to 8 matches per rule
+		Expect(first.Spec.Rules[0].Matches).To(HaveLen(8))
+		Expect(first.Spec.Rules[1].Matches).To(HaveLen(1))
+		Expect(first.Spec.Rules[1].BackendRefs).To(Equal(first.Spec.Rules[0].BackendRefs))
+		Expect(string(first.Spec.Rules[2].BackendRefs[0].Name)).To(Equal("service01"))
+		Expect(string(second.Spec.Rules[2].BackendRefs[0].Name)).To(Equal("service17"))
+	})
+
+	It("RateLimitPolicies target the split HTTPRoutes", func() {
+		var first, second kuadrantapiv1beta2.RateLimitPolicy
+		unmarshalList(run(generateKuadrantRateLimitPolicyCommand, "--compact-rules"), &first, &second)
+
+		Expect(first.Name).To(Equal("petstore"))
+		Expect(string(first.Spec.TargetRef.Name)).To(Equal("petstore"))
+		Expect(first.Spec.Limits).To(HaveLen(5))
+		Expect(first.Spec.Limits).To(HaveKey("getCat"))
+
+		Expect(second.Name).To(Equal("petstore-2"))
+		Expect(string(second.Spec.TargetRef.Name)).To(Equal("petstore-2"))
+		Expect(second.Spec.Limits).To(HaveLen(1))
+		Expect(second.Spec.Limits).To(HaveKey("getService17"))
+	})
+
+	It("AuthPolicies without authentication skipped", func() {
+		var kap kuadrantapiv1beta2.AuthPolicy
+		Expect(yaml.Unmarshal(run(generateKuadrantAuthPolicyCommand, "--compact-rules"), &kap)).ShouldNot(HaveOccurred())
+		Expect(string(kap.Spec.TargetRef.Name)).To(Equal("petstore"))
+		Expect(kap.Spec.AuthScheme.Authentication).To(HaveLen(1))
+	})
+
+	It("AuthPolicy top level route selectors compacted", func() {
+		cmd := generateKuadrantAuthPolicyCommand()
+		cmdStdoutBuffer := bytes.NewBufferString("")
+		cmd.SetOut(cmdStdoutBuffer)
+		cmd.SetErr(bytes.NewBufferString(""))
+		// 40 secured operations routed to the same backend
+		cmd.SetArgs([]string{"--oas", "testdata/petstore_many_secured_operations.yaml", "--compact-rules"})
+		Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+
+		var kap kuadrantapiv1beta2.AuthPolicy
+		Expect(yaml.Unmarshal(cmdStdoutBuffer.Bytes(), &kap)).ShouldNot(HaveOccurred())
+		Expect(kap.Spec.RouteSelectors).To(HaveLen(5))
+		Expect(kap.Spec.RouteSelectors[0].Matches).To(HaveLen(8))
+	})
+
+	It("AuthPolicy with too many top level route selectors rejected", func() {
+		cmd := generateKuadrantAuthPolicyCommand()
+		cmd.SetOut(bytes.NewBufferString(""))
+		cmd.SetErr(bytes.NewBufferString(""))
+		cmd.SetArgs([]string{"--oas", "testdata/petstore_many_secured_operations.yaml"})
+		Expect(cmd.Execute()).To(MatchError(`error #/paths: AuthPolicy "petstore" selects the secured operations with 40 route selectors, more than the 15 route selectors allowed`))
+	})
+
+	It("split HTTPRoutes require the route name", func() {
+		data, err := os.ReadFile(oas)
+		Expect(err).ShouldNot(HaveOccurred())
+		// without root kuadrant extension, the HTTPRoutes have no name
+		withoutRoute := regexp.MustCompile(`(?s)\nx-kuadrant:.*?\nservers:`).ReplaceAll(data, []byte("\nservers:"))
+		file := filepath.Join(GinkgoT().TempDir(), "petstore.yaml")
+		Expect(os.WriteFile(file, withoutRoute, 0o600)).To(Succeed())
+
+		cmd := generateGatewayApiHttpRouteCommand()
+		cmd.SetOut(bytes.NewBufferString(""))
+		cmd.SetErr(bytes.NewBufferString(""))
+		cmd.SetArgs([]string{"--oas", file, "--compact-rules"})
+		Expect(cmd.Execute()).To(MatchError("error #/x-kuadrant/route/name: openapi root kuadrant extension route name required to split the rules in several HTTPRoutes"))
+	})
+
+	It("policies of the HTTPRoutes routing no secured or rate limited operation skipped", func() {
+		data, err := os.ReadFile(oas)
+		Expect(err).ShouldNot(HaveOccurred())
+		// the only secured and rate limited operation is routed by the second HTTPRoute
+		doc := strings.Replace(string(data), "      security:\n        - api_key: []\n", "", 1)
+		doc = strings.Replace(doc, "      operationId: \"getService17\"\n",
+			"      operationId: \"getService17\"\n      security:\n        - api_key: []\n", 1)
+		doc = strings.Replace(doc,
+			"      rate_limit:\n        rates:\n          - limit: 1\n            duration: 10\n            unit: second\n    get:\n      operationId: \"getCat\"",
+			"    get:\n      operationId: \"getCat\"", 1)
+		file := filepath.Join(GinkgoT().TempDir(), "petstore.yaml")
+		Expect(os.WriteFile(file, []byte(doc), 0o600)).To(Succeed())
+
+		for _, newCommand := range []func() *cobra.Command{generateKuadrantAuthPolicyCommand, generateKuadrantRateLimitPolicyCommand} {
+			cmd := newCommand()
+			cmdStdoutBuffer := bytes.NewBufferString("")
+			cmd.SetOut(cmdStdoutBuffer)
+			cmd.SetErr(bytes.NewBufferString(""))
+			cmd.SetArgs([]string{"--oas", file, "--compact-rules"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+
+			var policy metav1.PartialObjectMetadata
+			Expect(yaml.Unmarshal(cmdStdoutBuffer.Bytes(), &policy)).ShouldNot(HaveOccurred())
+			Expect(policy.Name).To(Equal("petstore-2"))
+			Expect(splitYAMLDocuments(cmdStdoutBuffer.Bytes())).To(HaveLen(1))
+		}
+	})
+
+	It("bundle includes every HTTPRoute and policy", func() {
+		var (
+			firstRoute, secondRoute gatewayapiv1.HTTPRoute
+			kap                     kuadrantapiv1beta2.AuthPolicy
+			firstRLP, secondRLP     kuadrantapiv1beta2.RateLimitPolicy
+		)
+		unmarshalList(run(generateKuadrantBundleCommand, "--compact-rules"), &firstRoute, &secondRoute, &kap, &firstRLP, &secondRLP)
+
+		Expect(secondRoute.TypeMeta.Kind).To(Equal("HTTPRoute"))
+		Expect(kap.TypeMeta.Kind).To(Equal("AuthPolicy"))
+		Expect(string(secondRLP.Spec.TargetRef.Name)).To(Equal(secondRoute.Name))
+	})
+})
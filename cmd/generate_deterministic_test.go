@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+var _ = DescribeTable("Generated output is deterministic",
+	func(newCommand func() *cobra.Command, extraArgs ...string) {
+		run := func() string {
+			cmd := newCommand()
+			cmdStdoutBuffer := bytes.NewBufferString("")
+			cmd.SetOut(cmdStdoutBuffer)
+			cmd.SetErr(bytes.NewBufferString(""))
+			cmd.SetArgs(append([]string{"--oas", "testdata/petstore_openapi.yaml"}, extraArgs...))
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			return cmdStdoutBuffer.String()
+		}
+
+		expected := run()
+		for i := 0; i < 50; i++ {
+			Expect(run()).To(Equal(expected))
+		}
+	},
+	Entry("HTTPRoute", generateGatewayApiHttpRouteCommand),
+	Entry("HTTPRoute in spec order", generateGatewayApiHttpRouteCommand, "--operation-order", "spec"),
+	Entry("AuthPolicy", generateKuadrantAuthPolicyCommand),
+	Entry("RateLimitPolicy", generateKuadrantRateLimitPolicyCommand),
+	Entry("Bundle", generateKuadrantBundleCommand, "-o", "json"),
+)
+
+var _ = Describe("Generated rules order", func() {
+	It("unknown operation order rejected", func() {
+		cmd := generateGatewayApiHttpRouteCommand()
+		cmd.SetOut(bytes.NewBufferString(""))
+		cmd.SetErr(bytes.NewBufferString(""))
+		cmd.SetArgs([]string{"--oas", "testdata/petstore_openapi.yaml", "--operation-order", "random"})
+		Expect(cmd.Execute()).Should(MatchError(ContainSubstring("unknown operation order")))
+	})
+})
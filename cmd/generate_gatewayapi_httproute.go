@@ -1,36 +1,35 @@
 package cmd
 
 import (
-	"encoding/json"
-	"fmt"
-
 	"github.com/getkin/kin-openapi/openapi3"
-	"github.com/ghodss/yaml"
 	"github.com/kuadrant/kuadrantctl/pkg/gatewayapi"
 	"github.com/kuadrant/kuadrantctl/pkg/utils"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
 var (
-	generateGatewayAPIHTTPRouteOAS    string
+	generateGatewayAPIHTTPRouteOAS    []string
 	generateGatewayAPIHTTPRouteFormat string
 )
 
-//kuadrantctl generate gatewayapi httproute --oas [OAS_FILE_PATH | OAS_URL | @]
+//kuadrantctl generate gatewayapi httproute --oas [OAS_FILE_PATH | OAS_DIR | OAS_GLOB | OAS_URL | @]...
 
 func generateGatewayApiHttpRouteCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "httproute",
-		Short: "Generate Gateway API HTTPRoute from OpenAPI 3.0.X",
-		Long:  "Generate Gateway API HTTPRoute from OpenAPI 3.0.X",
+		Short: "Generate Gateway API HTTPRoute from OpenAPI 3.0.X or 3.1.X",
+		Long:  "Generate Gateway API HTTPRoute from OpenAPI 3.0.X or 3.1.X",
 		RunE:  runGenerateGatewayApiHttpRoute,
 	}
 
 	// OpenAPI ref
-	cmd.Flags().StringVar(&generateGatewayAPIHTTPRouteOAS, "oas", "", "Path to OpenAPI spec file (in JSON or YAML format), URL, or '-' to read from standard input (required)")
+	cmd.Flags().StringArrayVar(&generateGatewayAPIHTTPRouteOAS, "oas", nil, "Path to OpenAPI spec file (in JSON or YAML format), directory, glob pattern, URL, or '-' to read from standard input. Can be repeated to generate the resources of several documents (required)")
+	addOASReadFlags(cmd)
 	cmd.Flags().StringVarP(&generateGatewayAPIHTTPRouteFormat, "output-format", "o", "yaml", "Output format: 'yaml' or 'json'.")
+	addGenerateOptionsFlags(cmd)
 	err := cmd.MarkFlagRequired("oas")
 	if err != nil {
 		panic(err)
@@ -40,55 +39,69 @@ func generateGatewayApiHttpRouteCommand() *cobra.Command {
 }
 
 func runGenerateGatewayApiHttpRoute(cmd *cobra.Command, args []string) error {
-	oasDataRaw, err := utils.ReadExternalResource(generateGatewayAPIHTTPRouteOAS)
-	if err != nil {
-		return err
-	}
+	objects, err := generateObjects(cmd, generateGatewayAPIHTTPRouteOAS, func(doc *openapi3.T, opts *utils.GenerateOptions) ([]client.Object, error) {
+		httpRoutes, err := buildHTTPRoutes(doc, opts)
+		if err != nil {
+			return nil, err
+		}
 
-	openapiLoader := openapi3.NewLoader()
-	doc, err := openapiLoader.LoadFromData(oasDataRaw)
+		objects := make([]client.Object, 0, len(httpRoutes))
+		for _, httpRoute := range httpRoutes {
+			objects = append(objects, httpRoute)
+		}
+		return objects, nil
+	})
 	if err != nil {
 		return err
 	}
 
-	err = doc.Validate(openapiLoader.Context)
-	if err != nil {
-		return fmt.Errorf("OpenAPI validation error: %w", err)
-	}
+	return printGeneratedObjects(cmd, generateGatewayAPIHTTPRouteFormat, objects)
+}
 
-	httpRoute := buildHTTPRoute(doc)
-	jsonBytes, err := json.Marshal(httpRoute)
-	if err != nil {
-		return err
+// buildHTTPRoutes returns a single HTTPRoute unless rule compaction is enabled
+// and the rules exceed the Gateway API limits. The HTTPRoutes share the parentRefs and hostnames.
+func buildHTTPRoutes(doc *openapi3.T, opts *utils.GenerateOptions) ([]*gatewayapiv1.HTTPRoute, error) {
+	var problems utils.Problems
+
+	objectMeta, err := gatewayapi.HTTPRouteObjectMetaFromOAS(doc)
+	problems.Append(err)
+
+	parentRefs, err := gatewayapi.HTTPRouteGatewayParentRefsFromOAS(doc)
+	problems.Append(err)
+
+	hostnames, err := gatewayapi.HTTPRouteHostnamesFromOAS(doc, opts)
+	problems.Append(err)
+
+	rulesByRoute, err := gatewayapi.HTTPRouteRulesByRouteFromOAS(doc, opts)
+	problems.Append(err)
+
+	if err := problems.ErrorOrNil(); err != nil {
+		return nil, err
 	}
 
-	var outputBytes []byte
-	if generateGatewayAPIHTTPRouteFormat == "json" {
-		outputBytes = jsonBytes
-	} else {
-		outputBytes, err = yaml.JSONToYAML(jsonBytes) // use `omitempty`'s from the json Marshal
+	httpRoutes := make([]*gatewayapiv1.HTTPRoute, 0, len(rulesByRoute))
+	for idx, rules := range rulesByRoute {
+		routeObjectMeta := *objectMeta.DeepCopy()
+		routeObjectMeta.Name, err = gatewayapi.SplitHTTPRouteName(objectMeta.Name, idx)
 		if err != nil {
-			return err
+			return nil, err
 		}
-	}
-
-	fmt.Fprintln(cmd.OutOrStdout(), string(outputBytes))
-	return nil
-}
 
-func buildHTTPRoute(doc *openapi3.T) *gatewayapiv1.HTTPRoute {
-	return &gatewayapiv1.HTTPRoute{
-		TypeMeta: v1.TypeMeta{
-			APIVersion: gatewayapiv1.GroupVersion.String(),
-			Kind:       "HTTPRoute",
-		},
-		ObjectMeta: gatewayapi.HTTPRouteObjectMetaFromOAS(doc),
-		Spec: gatewayapiv1.HTTPRouteSpec{
-			CommonRouteSpec: gatewayapiv1.CommonRouteSpec{
-				ParentRefs: gatewayapi.HTTPRouteGatewayParentRefsFromOAS(doc),
+		httpRoutes = append(httpRoutes, &gatewayapiv1.HTTPRoute{
+			TypeMeta: v1.TypeMeta{
+				APIVersion: gatewayapiv1.GroupVersion.String(),
+				Kind:       "HTTPRoute",
 			},
-			Hostnames: gatewayapi.HTTPRouteHostnamesFromOAS(doc),
-			Rules:     gatewayapi.HTTPRouteRulesFromOAS(doc),
-		},
+			ObjectMeta: routeObjectMeta,
+			Spec: gatewayapiv1.HTTPRouteSpec{
+				CommonRouteSpec: gatewayapiv1.CommonRouteSpec{
+					ParentRefs: parentRefs,
+				},
+				Hostnames: hostnames,
+				Rules:     rules,
+			},
+		})
 	}
+
+	return httpRoutes, nil
 }
@@ -138,4 +138,59 @@ var _ = Describe("Generate HTTPRoute", func() {
 			))
 		})
 	})
+
+	Context("with templated paths", func() {
+		generate := func(args ...string) *gatewayapiv1.HTTPPathMatch {
+			cmd.SetArgs(append([]string{"--oas", "testdata/petstore_path_templates.yaml"}, args...))
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+
+			var httpRoute gatewayapiv1.HTTPRoute
+			Expect(yaml.Unmarshal(cmdStdoutBuffer.Bytes(), &httpRoute)).ShouldNot(HaveOccurred())
+			Expect(httpRoute.Spec.Rules).To(HaveLen(1))
+			return httpRoute.Spec.Rules[0].Matches[0].Path
+		}
+
+		It("regular expression from the path parameter schema", func() {
+			Expect(generate()).To(Equal(&gatewayapiv1.HTTPPathMatch{
+				Type:  ptr.To(gatewayapiv1.PathMatchRegularExpression),
+				Value: ptr.To(`^/v1/pets/-?[0-9]+$`),
+			}))
+		})
+
+		It("prefix fallback", func() {
+			Expect(generate("--path-template-match", "prefix")).To(Equal(&gatewayapiv1.HTTPPathMatch{
+				Type:  ptr.To(gatewayapiv1.PathMatchPathPrefix),
+				Value: ptr.To("/v1/pets"),
+			}))
+		})
+
+		It("unknown path template match rejected", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_path_templates.yaml", "--path-template-match", "glob"})
+			Expect(cmd.Execute()).Should(MatchError(ContainSubstring(`unknown path template match "glob"`)))
+		})
+	})
+
+	Context("with header and query parameters", func() {
+		It("match values derived from the parameters", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_parameters.yaml"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+
+			var httpRoute gatewayapiv1.HTTPRoute
+			Expect(yaml.Unmarshal(cmdStdoutBuffer.Bytes(), &httpRoute)).ShouldNot(HaveOccurred())
+			Expect(httpRoute.Spec.Rules).To(HaveLen(1))
+			match := httpRoute.Spec.Rules[0].Matches[0]
+			Expect(match.Headers).To(HaveExactElements(
+				gatewayapiv1.HTTPHeaderMatch{Type: ptr.To(gatewayapiv1.HeaderMatchExact), Name: "X-Pet-Kind", Value: "dog"},
+				gatewayapiv1.HTTPHeaderMatch{Type: ptr.To(gatewayapiv1.HeaderMatchRegularExpression), Name: "X-Tenant", Value: "^acme-"},
+				gatewayapiv1.HTTPHeaderMatch{Type: ptr.To(gatewayapiv1.HeaderMatchRegularExpression), Name: "X-Api-Version", Value: "^(?:v[0-9]+)$"},
+			))
+			Expect(match.QueryParams).To(HaveExactElements(
+				gatewayapiv1.HTTPQueryParamMatch{Type: ptr.To(gatewayapiv1.QueryParamMatchRegularExpression), Name: "color", Value: "^(?:black|white)$"},
+				gatewayapiv1.HTTPQueryParamMatch{Type: ptr.To(gatewayapiv1.QueryParamMatchRegularExpression), Name: "tag", Value: "^.*(?:[0-9]).*$"},
+			))
+			Expect(cmdStderrBuffer.String()).To(Equal(
+				`Warning: #/paths/~1pets/get/parameters/1: required header parameter "X-Request-Id" not matched, the value cannot be derived from the schema: set an enum or a pattern, or the kuadrant extension match` + "\n",
+			))
+		})
+	})
 })
@@ -13,6 +13,7 @@ func generateKuadrantCommand() *cobra.Command {
 
 	cmd.AddCommand(generateKuadrantRateLimitPolicyCommand())
 	cmd.AddCommand(generateKuadrantAuthPolicyCommand())
+	cmd.AddCommand(generateKuadrantBundleCommand())
 
 	return cmd
 }
@@ -1,14 +1,11 @@
 package cmd
 
 import (
-	"encoding/json"
-	"fmt"
-
 	"github.com/getkin/kin-openapi/openapi3"
-	"github.com/ghodss/yaml"
 	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
@@ -18,23 +15,25 @@ import (
 )
 
 var (
-	generateAuthPolicyOAS    string
+	generateAuthPolicyOAS    []string
 	generateAuthPolicyFormat string
 )
 
-//kuadrantctl generate kuadrant authpolicy --oas [OAS_FILE_PATH | OAS_URL | @]
+//kuadrantctl generate kuadrant authpolicy --oas [OAS_FILE_PATH | OAS_DIR | OAS_GLOB | OAS_URL | @]...
 
 func generateKuadrantAuthPolicyCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "authpolicy",
-		Short: "Generate Kuadrant AuthPolicy from OpenAPI 3.0.X",
-		Long:  "Generate Kuadrant AuthPolicy from OpenAPI 3.0.X",
+		Short: "Generate Kuadrant AuthPolicy from OpenAPI 3.0.X or 3.1.X",
+		Long:  "Generate Kuadrant AuthPolicy from OpenAPI 3.0.X or 3.1.X",
 		RunE:  runGenerateKuadrantAuthPolicy,
 	}
 
 	// OpenAPI ref
-	cmd.Flags().StringVar(&generateAuthPolicyOAS, "oas", "", "Path to OpenAPI spec file (in JSON or YAML format), URL, or '-' to read from standard input (required)")
+	cmd.Flags().StringArrayVar(&generateAuthPolicyOAS, "oas", nil, "Path to OpenAPI spec file (in JSON or YAML format), directory, glob pattern, URL, or '-' to read from standard input. Can be repeated to generate the resources of several documents (required)")
+	addOASReadFlags(cmd)
 	cmd.Flags().StringVarP(&generateAuthPolicyFormat, "output-format", "o", "yaml", "Output format: 'yaml' or 'json'.")
+	addGenerateOptionsFlags(cmd)
 	err := cmd.MarkFlagRequired("oas")
 	if err != nil {
 		panic(err)
@@ -44,63 +43,92 @@ func generateKuadrantAuthPolicyCommand() *cobra.Command {
 }
 
 func runGenerateKuadrantAuthPolicy(cmd *cobra.Command, args []string) error {
-	oasDataRaw, err := utils.ReadExternalResource(generateAuthPolicyOAS)
-	if err != nil {
-		return err
-	}
+	objects, err := generateObjects(cmd, generateAuthPolicyOAS, func(doc *openapi3.T, opts *utils.GenerateOptions) ([]client.Object, error) {
+		policies, err := buildAuthPolicies(doc, opts)
+		if err != nil {
+			return nil, err
+		}
 
-	openapiLoader := openapi3.NewLoader()
-	doc, err := openapiLoader.LoadFromData(oasDataRaw)
+		objects := make([]client.Object, 0, len(policies))
+		for _, policy := range policies {
+			objects = append(objects, policy)
+		}
+		return objects, nil
+	})
 	if err != nil {
 		return err
 	}
 
-	err = doc.Validate(openapiLoader.Context)
-	if err != nil {
-		return fmt.Errorf("OpenAPI validation error: %w", err)
-	}
+	return printGeneratedObjects(cmd, generateAuthPolicyFormat, objects)
+}
 
-	ap := buildAuthPolicy(doc)
-	jsonBytes, err := json.Marshal(ap)
+// buildAuthPolicies returns one AuthPolicy per HTTPRoute, see buildHTTPRoutes
+func buildAuthPolicies(doc *openapi3.T, opts *utils.GenerateOptions) ([]*kuadrantapiv1beta2.AuthPolicy, error) {
+	ap, err := buildAuthPolicy(doc, opts)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var outputBytes []byte
-	if generateAuthPolicyFormat == "json" {
-		outputBytes = jsonBytes
-	} else {
-		outputBytes, err = yaml.JSONToYAML(jsonBytes) // use `omitempty`'s from the json Marshal
+	policies := []*kuadrantapiv1beta2.AuthPolicy{ap}
+	if opts.GetCompactRules() {
+		httpRoutes, err := buildHTTPRoutes(doc, opts)
 		if err != nil {
-			return err
+			return nil, err
 		}
+
+		policies = kuadrantapi.AuthPoliciesForHTTPRoutes(ap, httpRoutes)
 	}
 
-	fmt.Fprintln(cmd.OutOrStdout(), string(outputBytes))
-	return nil
+	if err := kuadrantapi.CheckAuthPolicyRouteSelectors(policies); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
 }
 
-func buildAuthPolicy(doc *openapi3.T) *kuadrantapiv1beta2.AuthPolicy {
-	routeMeta := gatewayapi.HTTPRouteObjectMetaFromOAS(doc)
+func buildAuthPolicy(doc *openapi3.T, opts *utils.GenerateOptions) (*kuadrantapiv1beta2.AuthPolicy, error) {
+	var problems utils.Problems
+
+	routeMeta, err := gatewayapi.HTTPRouteObjectMetaFromOAS(doc)
+	problems.Append(err)
+
+	objectMeta, err := kuadrantapi.AuthPolicyObjectMetaFromOAS(doc)
+	problems.Append(err)
+
+	authentication, err := kuadrantapi.AuthPolicyAuthenticationSchemeFromOAS(doc, opts)
+	problems.Append(err)
+
+	authorization, err := kuadrantapi.AuthPolicyAuthorizationFromOAS(doc, opts)
+	problems.Append(err)
+
+	authScheme, err := kuadrantapi.AuthPolicyAuthSchemeExtensionFromOAS(doc, opts, authorization)
+	problems.Append(err)
+
+	routeSelectors, err := kuadrantapi.AuthPolicyTopRouteSelectorsFromOAS(doc, opts)
+	problems.Append(err)
+
+	if err := problems.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
+	// Authentication generated from the security requirements, other rules from the kuadrant extensions
+	authScheme.Authentication = authentication
 
 	ap := &kuadrantapiv1beta2.AuthPolicy{
 		TypeMeta: v1.TypeMeta{
 			APIVersion: "kuadrant.io/v1beta2",
 			Kind:       "AuthPolicy",
 		},
-		ObjectMeta: kuadrantapi.AuthPolicyObjectMetaFromOAS(doc),
+		ObjectMeta: objectMeta,
 		Spec: kuadrantapiv1beta2.AuthPolicySpec{
 			TargetRef: gatewayapiv1alpha2.PolicyTargetReference{
 				Group: gatewayapiv1.GroupName,
 				Kind:  gatewayapiv1.Kind("HTTPRoute"),
 				Name:  gatewayapiv1.ObjectName(routeMeta.Name),
 			},
-			// Currently only authentication rules enforced
 			AuthPolicyCommonSpec: kuadrantapiv1beta2.AuthPolicyCommonSpec{
-				AuthScheme: &kuadrantapiv1beta2.AuthSchemeSpec{
-					Authentication: kuadrantapi.AuthPolicyAuthenticationSchemeFromOAS(doc),
-				},
-				RouteSelectors: kuadrantapi.AuthPolicyTopRouteSelectorsFromOAS(doc),
+				AuthScheme:     authScheme,
+				RouteSelectors: routeSelectors,
 			},
 		},
 	}
@@ -111,5 +139,5 @@ func buildAuthPolicy(doc *openapi3.T) *kuadrantapiv1beta2.AuthPolicy {
 		}[0]
 	}
 
-	return ap
+	return ap, nil
 }
@@ -2,11 +2,18 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
 	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
@@ -15,6 +22,8 @@ import (
 
 	authorinoapi "github.com/kuadrant/authorino/api/v1beta2"
 	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
 )
 
 var _ = Describe("Generate AuthPolicy", func() {
@@ -40,6 +49,451 @@ var _ = Describe("Generate AuthPolicy", func() {
 		})
 	})
 
+	Context("with AND'ed security requirements", func() {
+		It("every security scheme enforced", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_and_security.yaml"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			out, err := io.ReadAll(cmdStdoutBuffer)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			var kap kuadrantapiv1beta2.AuthPolicy
+			Expect(yaml.Unmarshal(out, &kap)).ShouldNot(HaveOccurred())
+
+			// the identity is resolved from the API keys first
+			authentication := kap.Spec.AuthScheme.Authentication
+			Expect(authentication).To(HaveLen(3))
+			Expect(authentication["apiKeyPets"].Priority).To(Equal(0))
+			Expect(authentication["apiKeySnakes"].Priority).To(Equal(0))
+			Expect(authentication["oidcPets"].Priority).To(Equal(1))
+
+			// OR'ed security schemes need no authorization rule
+			authorization := kap.Spec.AuthScheme.Authorization
+			Expect(authorization).To(HaveLen(3))
+			Expect(authorization).To(HaveKey("getBird_security"))
+
+			getDog := authorization["getDog_security"]
+			Expect(getDog.RouteSelectors).To(HaveExactElements(kuadrantapiv1beta2.RouteSelector{
+				Matches: []gatewayapiv1.HTTPRouteMatch{
+					{
+						Path: &gatewayapiv1.HTTPPathMatch{
+							Type:  ptr.To(gatewayapiv1.PathMatchExact),
+							Value: ptr.To("/v1/dog"),
+						},
+						Method: ptr.To(gatewayapiv1.HTTPMethodGet),
+					},
+				},
+			}))
+			Expect(getDog.Opa).ToNot(BeNil())
+			// the JWTs verified against the keys of the issuer
+			Expect(getDog.Opa.Rego).To(ContainSubstring(`
+# oidcPets
+jwt_0_config := response.body {
+  response := http.send({"method": "GET", "url": "https://example.com/.well-known/openid-configuration", "force_cache": true, "force_cache_duration_seconds": 3600, "raise_error": false})
+  response.status_code == 200
+}
+`))
+			Expect(getDog.Opa.Rego).To(HaveSuffix(`
+# security requirement 0
+allow {
+  input.auth.identity.metadata.labels["kuadrant.io/apikeys-by"] == "apiKeyPets"
+  jwt_0
+}
+`))
+
+			Expect(authorization["getSnake_security"].Opa.Rego).To(HaveSuffix(`
+# security requirement 0
+allow {
+  input.auth.identity.metadata.labels["kuadrant.io/apikeys-by"] == "apiKeyPets"
+  jwt_0
+}
+
+# security requirement 1
+allow {
+  input.auth.identity.metadata.labels["kuadrant.io/apikeys-by"] == "apiKeySnakes"
+}
+`))
+
+			// the scopes verified with the JWTs
+			Expect(authorization["getBird_security"].Opa.Rego).To(HaveSuffix(`
+# security requirement 0
+allow {
+  input.auth.identity.metadata.labels["kuadrant.io/apikeys-by"] == "apiKeyPets"
+  scope_granted(jwt_0.scope, "read:birds")
+}
+`))
+		})
+
+		It("security requirements with several security schemes verified against Secrets rejected", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore.yaml"})
+			err := cmd.Execute()
+
+			var problems utils.Problems
+			Expect(errors.As(err, &problems)).To(BeTrue())
+			Expect(problems).To(ConsistOf(
+				utils.NewError("#/security/0",
+					"security requirement with several security schemes verified against Secrets (apiKey, appId) not supported: AuthPolicy authentication resolves a single identity"),
+			))
+		})
+
+		It("security schemes of tokens validated by introspection rejected", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_and_introspection.yaml"})
+			err := cmd.Execute()
+
+			var problems utils.Problems
+			Expect(errors.As(err, &problems)).To(BeTrue())
+			Expect(problems).To(ConsistOf(
+				utils.NewError("#/components/securitySchemes/oauth2Pets",
+					`security scheme "oauth2Pets" not supported in operations with security requirements of several security schemes: tokens validated by introspection are only verified by the authentication`),
+			))
+		})
+	})
+
+	Context("with security schemes shared by several operations", func() {
+		It("one authentication rule per security scheme", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_shared_security.yaml"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			out, err := io.ReadAll(cmdStdoutBuffer)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			var kap kuadrantapiv1beta2.AuthPolicy
+			Expect(yaml.Unmarshal(out, &kap)).ShouldNot(HaveOccurred())
+			Expect(kap.Spec.AuthScheme.Authentication).To(HaveLen(3))
+
+			// the matches of the operations are grouped in one route selector
+			apiKeyPets := kap.Spec.AuthScheme.Authentication["apiKeyPets"]
+			Expect(apiKeyPets.RouteSelectors).To(HaveLen(1))
+			Expect(apiKeyPets.RouteSelectors[0].Matches).To(HaveLen(3))
+			Expect(kap.Spec.AuthScheme.Authentication["apiKeySnakes"].RouteSelectors[0].Matches).To(HaveLen(1))
+		})
+
+		It("one authentication rule per operation and security scheme on demand", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_shared_security.yaml", "--authentication-per-operation"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			out, err := io.ReadAll(cmdStdoutBuffer)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			var kap kuadrantapiv1beta2.AuthPolicy
+			Expect(yaml.Unmarshal(out, &kap)).ShouldNot(HaveOccurred())
+			Expect(kap.Spec.AuthScheme.Authentication).To(HaveLen(6))
+			Expect(kap.Spec.AuthScheme.Authentication).To(HaveKey("getDog_apiKeyPets"))
+			Expect(kap.Spec.AuthScheme.Authentication).To(HaveKey("getDog_oidcPets"))
+			Expect(kap.Spec.AuthScheme.Authentication["getDog_apiKeyPets"].RouteSelectors).To(Equal(
+				kap.Spec.AuthScheme.Authentication["getDog_oidcPets"].RouteSelectors,
+			))
+		})
+	})
+
+	Context("with oauth2 security schemes", func() {
+		It("tokens validated and scopes authorized", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_oauth2.yaml"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			out, err := io.ReadAll(cmdStdoutBuffer)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			var kap kuadrantapiv1beta2.AuthPolicy
+			Expect(yaml.Unmarshal(out, &kap)).ShouldNot(HaveOccurred())
+
+			authentication := kap.Spec.AuthScheme.Authentication
+			Expect(authentication).To(HaveLen(4))
+			Expect(authentication["introspectedPets"].OAuth2TokenIntrospection).To(Equal(&authorinoapi.OAuth2TokenIntrospectionSpec{
+				Url:         "https://keycloak.example.com/realms/petstore/protocol/openid-connect/token/introspect",
+				Credentials: &corev1.LocalObjectReference{Name: "petstore-introspection"},
+			}))
+			Expect(authentication["keycloakPets"].Jwt).To(Equal(&authorinoapi.JwtAuthenticationSpec{
+				IssuerUrl: "https://keycloak.example.com/realms/petstore",
+			}))
+			Expect(authentication["issuerPets"].Jwt).To(Equal(&authorinoapi.JwtAuthenticationSpec{
+				IssuerUrl: "https://issuer.example.com",
+			}))
+
+			authorization := kap.Spec.AuthScheme.Authorization
+			Expect(authorization).To(HaveLen(2))
+
+			getDog := authorization["getDog_scopes"]
+			Expect(getDog.RouteSelectors).To(Equal(authentication["keycloakPets"].RouteSelectors))
+			Expect(getDog.Conditions).To(BeEmpty())
+			Expect(getDog.PatternMatching.Patterns).To(HaveExactElements(
+				authorinoapi.PatternExpressionOrRef{PatternExpression: authorinoapi.PatternExpression{
+					Selector: "auth.identity.scope", Operator: "matches", Value: `(?:^|\s)read:dogs(?:\s|$)`,
+				}},
+				authorinoapi.PatternExpressionOrRef{PatternExpression: authorinoapi.PatternExpression{
+					Selector: "auth.identity.scope", Operator: "matches", Value: `(?:^|\s)write:dogs(?:\s|$)`,
+				}},
+			))
+
+			// the API key alternative is not subject to the scopes
+			getCat := authorization["getCat_scopes"]
+			Expect(getCat.Conditions).To(HaveExactElements(
+				authorinoapi.PatternExpressionOrRef{PatternExpression: authorinoapi.PatternExpression{
+					Selector: "context.request.http.headers.authorization", Operator: "matches", Value: `^[Bb]earer\s+\S+`,
+				}},
+			))
+		})
+
+		It("missing introspection credentials reported", func() {
+			cmd.SilenceUsage = true
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_oauth2_introspection_invalid.yaml"})
+			Expect(cmd.Execute()).Should(MatchError(ContainSubstring(
+				"error #/components/securitySchemes/petstore_oauth2/x-kuadrant: token introspection requires the kuadrant extension introspection credentialsRef",
+			)))
+		})
+	})
+
+	Context("with scopes in the security requirements", func() {
+		It("scopes authorized from the configured claim", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_scopes.yaml"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			out, err := io.ReadAll(cmdStdoutBuffer)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			var kap kuadrantapiv1beta2.AuthPolicy
+			Expect(yaml.Unmarshal(out, &kap)).ShouldNot(HaveOccurred())
+
+			// one authorization rule per operation
+			authorization := kap.Spec.AuthScheme.Authorization
+			Expect(authorization).To(HaveLen(5))
+
+			getCat := authorization["getCat_scopes"]
+			Expect(getCat.RouteSelectors).To(HaveExactElements(kuadrantapiv1beta2.RouteSelector{
+				Matches: []gatewayapiv1.HTTPRouteMatch{
+					{
+						Path: &gatewayapiv1.HTTPPathMatch{
+							Type:  ptr.To(gatewayapiv1.PathMatchExact),
+							Value: ptr.To("/v1/cat"),
+						},
+						Method: ptr.To(gatewayapiv1.HTTPMethodGet),
+					},
+				},
+			}))
+			Expect(getCat.PatternMatching.Patterns).To(HaveExactElements(
+				authorinoapi.PatternExpressionOrRef{PatternExpression: authorinoapi.PatternExpression{
+					Selector: "auth.identity.scope", Operator: "matches", Value: `(?:^|\s)read:cats(?:\s|$)`,
+				}},
+			))
+
+			Expect(authorization["getDog_scopes"].PatternMatching.Patterns).To(HaveExactElements(
+				authorinoapi.PatternExpressionOrRef{PatternExpression: authorinoapi.PatternExpression{
+					Selector: "auth.identity.roles", Operator: "incl", Value: "dog-reader",
+				}},
+			))
+
+			Expect(authorization["getSnake_scopes"].PatternMatching.Patterns).To(HaveExactElements(
+				authorinoapi.PatternExpressionOrRef{Any: []authorinoapi.UnstructuredPatternExpressionOrRef{
+					{PatternExpressionOrRef: authorinoapi.PatternExpressionOrRef{PatternExpression: authorinoapi.PatternExpression{
+						Selector: "auth.identity.scp", Operator: "incl", Value: "read:snakes",
+					}}},
+					{PatternExpressionOrRef: authorinoapi.PatternExpressionOrRef{PatternExpression: authorinoapi.PatternExpression{
+						Selector: "auth.identity.scp", Operator: "matches", Value: `(?:^|\s)read:snakes(?:\s|$)`,
+					}}},
+				}},
+			))
+
+			// the scopes of any of the security requirements
+			scopePattern := func(scope string) authorinoapi.UnstructuredPatternExpressionOrRef {
+				return authorinoapi.UnstructuredPatternExpressionOrRef{PatternExpressionOrRef: authorinoapi.PatternExpressionOrRef{
+					PatternExpression: authorinoapi.PatternExpression{
+						Selector: "auth.identity.scope", Operator: "matches", Value: `(?:^|\s)` + scope + `(?:\s|$)`,
+					},
+				}}
+			}
+			getBird := authorization["getBird_scopes"]
+			Expect(getBird.Conditions).To(BeEmpty())
+			Expect(getBird.PatternMatching.Patterns).To(HaveExactElements(
+				authorinoapi.PatternExpressionOrRef{Any: []authorinoapi.UnstructuredPatternExpressionOrRef{
+					scopePattern("read:birds"),
+					{PatternExpressionOrRef: authorinoapi.PatternExpressionOrRef{All: []authorinoapi.UnstructuredPatternExpressionOrRef{
+						scopePattern("write:birds"), scopePattern("admin"),
+					}}},
+				}},
+			))
+
+			// the scopes of the token security scheme of the security requirement, told apart by their issuer
+			issuerPattern := func(issuer string) authorinoapi.UnstructuredPatternExpressionOrRef {
+				return authorinoapi.UnstructuredPatternExpressionOrRef{PatternExpressionOrRef: authorinoapi.PatternExpressionOrRef{
+					PatternExpression: authorinoapi.PatternExpression{Selector: "auth.identity.iss", Operator: "eq", Value: issuer},
+				}}
+			}
+			getFish := authorization["getFish_scopes"]
+			Expect(getFish.Conditions).To(BeEmpty())
+			Expect(getFish.PatternMatching.Patterns).To(HaveExactElements(
+				authorinoapi.PatternExpressionOrRef{Any: []authorinoapi.UnstructuredPatternExpressionOrRef{
+					{PatternExpressionOrRef: authorinoapi.PatternExpressionOrRef{All: []authorinoapi.UnstructuredPatternExpressionOrRef{
+						issuerPattern("https://fish.example.com"), scopePattern("pets:read"),
+					}}},
+					{PatternExpressionOrRef: authorinoapi.PatternExpressionOrRef{All: []authorinoapi.UnstructuredPatternExpressionOrRef{
+						issuerPattern("https://example.com"), scopePattern("read:fish"),
+					}}},
+				}},
+			))
+		})
+	})
+
+	Context("with http security schemes", func() {
+		It("bearer and basic schemes authenticated", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_http_security.yaml"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			out, err := io.ReadAll(cmdStdoutBuffer)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			var kap kuadrantapiv1beta2.AuthPolicy
+			Expect(yaml.Unmarshal(out, &kap)).ShouldNot(HaveOccurred())
+
+			authentication := kap.Spec.AuthScheme.Authentication
+			Expect(authentication).To(HaveLen(2))
+			Expect(authentication["bearerPets"].Jwt).To(Equal(&authorinoapi.JwtAuthenticationSpec{
+				IssuerUrl: "https://issuer.example.com",
+			}))
+			Expect(authentication["basicPets"].Credentials).To(Equal(authorinoapi.Credentials{
+				AuthorizationHeader: &authorinoapi.Prefixed{Prefix: "Basic"},
+			}))
+			Expect(authentication["basicPets"].ApiKey.Selector.MatchLabels).To(Equal(map[string]string{
+				"kuadrant.io/basic-auth-by": "basicPets",
+			}))
+
+			errOut, err := io.ReadAll(cmdStderrBuffer)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(errOut)).To(ContainSubstring(
+				`Warning: #/components/securitySchemes/digestPets/scheme: http security scheme "digest" not supported, ignored by the AuthPolicy generator`,
+			))
+		})
+
+		DescribeTable("bearer schemes without token validation settings reported",
+			func(extension, expected string) {
+				data, err := os.ReadFile("testdata/petstore_http_security.yaml")
+				Expect(err).ShouldNot(HaveOccurred())
+				doc := strings.Replace(string(data), "      x-kuadrant:\n        issuerUrl: https://issuer.example.com\n", extension, 1)
+				file := filepath.Join(GinkgoT().TempDir(), "petstore.yaml")
+				Expect(os.WriteFile(file, []byte(doc), 0o600)).To(Succeed())
+
+				cmd.SetArgs([]string{"--oas", file})
+				Expect(cmd.Execute()).Should(MatchError("error #/components/securitySchemes/bearerPets/x-kuadrant: " + expected))
+			},
+			Entry("issuer", "",
+				"token issuer required by http bearer security schemes: set the kuadrant extension issuerUrl, or the kuadrant extension tokenValidation introspection and introspection url"),
+			Entry("introspection endpoint", "      x-kuadrant:\n        tokenValidation: introspection\n        introspection:\n          credentialsRef: petstore-introspection\n",
+				"token introspection endpoint required by http bearer security schemes: set the kuadrant extension introspection url"),
+		)
+	})
+
+	Context("with auth kuadrant extensions", func() {
+		routeSelector := func(path string, method gatewayapiv1.HTTPMethod) kuadrantapiv1beta2.RouteSelector {
+			return kuadrantapiv1beta2.RouteSelector{
+				Matches: []gatewayapiv1.HTTPRouteMatch{
+					{
+						Path: &gatewayapiv1.HTTPPathMatch{
+							Type:  ptr.To(gatewayapiv1.PathMatchExact),
+							Value: ptr.To(path),
+						},
+						Method: ptr.To(method),
+					},
+				},
+			}
+		}
+
+		It("auth rules scoped by the operation route selectors", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_auth_extension.yaml"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			out, err := io.ReadAll(cmdStdoutBuffer)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			var kap kuadrantapiv1beta2.AuthPolicy
+			Expect(yaml.Unmarshal(out, &kap)).ShouldNot(HaveOccurred())
+
+			// operations with auth rules are protected, security requirements or not
+			Expect(kap.Spec.RouteSelectors).To(HaveExactElements(
+				routeSelector("/v1/cat", gatewayapiv1.HTTPMethodGet),
+				routeSelector("/v1/cat", gatewayapiv1.HTTPMethodPost),
+				routeSelector("/v1/dog", gatewayapiv1.HTTPMethodGet),
+			))
+
+			authScheme := kap.Spec.AuthScheme
+			Expect(authScheme.Authentication).To(HaveLen(1))
+
+			// the operation auth rules replace the path auth rules
+			Expect(authScheme.Authorization).To(HaveLen(2))
+			Expect(authScheme.Authorization["admins"].RouteSelectors).To(HaveExactElements(
+				routeSelector("/v1/cat", gatewayapiv1.HTTPMethodGet),
+			))
+			Expect(authScheme.Authorization["admins"].KubernetesSubjectAccessReview).ToNot(BeNil())
+			Expect(authScheme.Authorization["cat-owners"].RouteSelectors).To(HaveExactElements(
+				routeSelector("/v1/cat", gatewayapiv1.HTTPMethodPost),
+			))
+			Expect(authScheme.Metadata["user-info"].RouteSelectors).To(HaveExactElements(
+				routeSelector("/v1/cat", gatewayapiv1.HTTPMethodPost),
+			))
+			Expect(authScheme.Metadata["user-info"].Http.Url).To(Equal("https://users.example.com/info"))
+			Expect(authScheme.Callbacks["audit"].RouteSelectors).To(HaveExactElements(
+				routeSelector("/v1/cat", gatewayapiv1.HTTPMethodPost),
+			))
+
+			// the denial responses apply to every operation
+			Expect(authScheme.Response.Unauthenticated.Code).To(Equal(authorinoapi.DenyWithCode(302)))
+			Expect(authScheme.Response.Unauthenticated.Headers).To(HaveKey("location"))
+			Expect(authScheme.Response.Unauthorized).To(Equal(&authorinoapi.DenyWithSpec{Code: 404}))
+
+			// the same rule of several operations applies to all of them
+			Expect(authScheme.Response.Success.Headers).To(HaveLen(1))
+			Expect(authScheme.Response.Success.Headers["x-username"].RouteSelectors).To(HaveExactElements(
+				kuadrantapiv1beta2.RouteSelector{Matches: slices.Concat(
+					routeSelector("/v1/cat", gatewayapiv1.HTTPMethodGet).Matches,
+					routeSelector("/v1/dog", gatewayapiv1.HTTPMethodGet).Matches,
+				)},
+			))
+		})
+
+		// sharedAuthRuleOAS returns an OAS document of operations sharing the same path level auth rule
+		sharedAuthRuleOAS := func(operations int) string {
+			var doc strings.Builder
+			doc.WriteString("openapi: \"3.0.3\"\ninfo:\n  title: \"Pet Store API\"\n  version: \"1.0.0\"\n")
+			doc.WriteString("x-kuadrant:\n  route:\n    name: \"petstore\"\n    namespace: \"petstore-ns\"\n")
+			doc.WriteString("servers:\n  - url: https://example.io/v1\npaths:\n")
+			for idx := 1; idx <= operations; idx++ {
+				fmt.Fprintf(&doc, "  /pet%02[1]d:\n    x-kuadrant:\n      auth:\n        metadata:\n          user-info:\n            http:\n              url: https://users.example.com/info\n", idx)
+				fmt.Fprintf(&doc, "    get:\n      operationId: \"getPet%02[1]d\"\n      responses:\n        200:\n          description: \"ok\"\n", idx)
+			}
+
+			file := filepath.Join(GinkgoT().TempDir(), "petstore.yaml")
+			Expect(os.WriteFile(file, []byte(doc.String()), 0o600)).To(Succeed())
+			return file
+		}
+
+		It("auth rules shared by many operations compacted", func() {
+			cmd.SetArgs([]string{"--oas", sharedAuthRuleOAS(40), "--compact-rules"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			out, err := io.ReadAll(cmdStdoutBuffer)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			var kap kuadrantapiv1beta2.AuthPolicy
+			Expect(yaml.Unmarshal(out, &kap)).ShouldNot(HaveOccurred())
+			routeSelectors := kap.Spec.AuthScheme.Metadata["user-info"].RouteSelectors
+			Expect(routeSelectors).To(HaveLen(5))
+			for _, routeSelector := range routeSelectors {
+				Expect(routeSelector.Matches).To(HaveLen(8))
+			}
+		})
+
+		It("auth rules shared by too many operations reported", func() {
+			cmd.SetArgs([]string{"--oas", sharedAuthRuleOAS(65), "--compact-rules"})
+			Expect(cmd.Execute()).To(MatchError(
+				`error #/paths/~1pet01/x-kuadrant/auth/metadata/user-info: auth rule "user-info" selects more operations than fit in 8 route selectors of 8 matches`,
+			))
+		})
+
+		It("conflicting auth rules reported", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_auth_extension_conflict.yaml"})
+			err := cmd.Execute()
+
+			var problems utils.Problems
+			Expect(errors.As(err, &problems)).To(BeTrue())
+			Expect(problems).To(ConsistOf(
+				utils.NewError("#/paths/~1dog/get/x-kuadrant/auth/response/success/headers/x-username",
+					`auth rule "x-username" differs from the auth rule of the same name of another operation`),
+				utils.NewError("#/paths/~1snake/get/x-kuadrant/auth/authorization/getSnake_scopes",
+					`auth rule "getSnake_scopes" conflicts with the authorization rule of the same name generated from the security requirements`),
+			))
+		})
+	})
+
 	Context("with operation including security", func() {
 		It("authorization policy generated", func() {
 			cmd.SetArgs([]string{"--oas", "testdata/petstore_openapi.yaml"})
@@ -78,7 +532,7 @@ var _ = Describe("Generate AuthPolicy", func() {
 			Expect(kap.Spec.AuthPolicyCommonSpec.AuthScheme).To(Equal(
 				&kuadrantapiv1beta2.AuthSchemeSpec{
 					Authentication: map[string]kuadrantapiv1beta2.AuthenticationSpec{
-						"postDog_securedDog": kuadrantapiv1beta2.AuthenticationSpec{
+						"securedDog": kuadrantapiv1beta2.AuthenticationSpec{
 							AuthenticationSpec: authorinoapi.AuthenticationSpec{
 								Credentials: authorinoapi.Credentials{},
 								AuthenticationMethodSpec: authorinoapi.AuthenticationMethodSpec{
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kuadrant/kuadrantctl/pkg/kuadrantapi"
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+var (
+	generateBundleOAS       []string
+	generateBundleFormat    string
+	generateBundleOutputDir string
+)
+
+//kuadrantctl generate kuadrant bundle --oas [OAS_FILE_PATH | OAS_DIR | OAS_GLOB | OAS_URL | @]... [--output-dir DIR]
+
+func generateKuadrantBundleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Generate Gateway API HTTPRoute, Kuadrant AuthPolicy and Kuadrant RateLimitPolicy from OpenAPI 3.0.X or 3.1.X",
+		Long: `Generate Gateway API HTTPRoute, Kuadrant AuthPolicy and Kuadrant RateLimitPolicy from OpenAPI 3.0.X or 3.1.X.
+The OpenAPI document is read, parsed and validated only once.
+Policies with no rules are skipped.`,
+		RunE: runGenerateKuadrantBundle,
+	}
+
+	cmd.Flags().StringArrayVar(&generateBundleOAS, "oas", nil, "Path to OpenAPI spec file (in JSON or YAML format), directory, glob pattern, URL, or '-' to read from standard input. Can be repeated to generate the resources of several documents (required)")
+	addOASReadFlags(cmd)
+	cmd.Flags().StringVarP(&generateBundleFormat, "output-format", "o", "yaml", "Output format: 'yaml' or 'json'.")
+	cmd.Flags().StringVar(&generateBundleOutputDir, "output-dir", "", "Directory to write one file per resource. When not set, resources are written to standard output")
+	addGenerateOptionsFlags(cmd)
+	err := cmd.MarkFlagRequired("oas")
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func runGenerateKuadrantBundle(cmd *cobra.Command, args []string) error {
+	objects, err := generateObjects(cmd, generateBundleOAS, buildBundle)
+	if err != nil {
+		return err
+	}
+
+	if generateBundleOutputDir != "" {
+		return writeBundleToDir(generateBundleOutputDir, generateBundleFormat, objects)
+	}
+
+	outputBytes, err := marshalBundle(generateBundleFormat, objects)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(cmd.OutOrStdout(), string(outputBytes))
+	return nil
+}
+
+// buildBundle returns the HTTPRoutes followed by the AuthPolicies and the RateLimitPolicies.
+// Policies without rules are not included.
+// The problems found by every builder are reported together.
+func buildBundle(doc *openapi3.T, opts *utils.GenerateOptions) ([]client.Object, error) {
+	var problems utils.Problems
+
+	httpRoutes, err := buildHTTPRoutes(doc, opts)
+	problems.Append(err)
+
+	ap, err := buildAuthPolicy(doc, opts)
+	problems.Append(err)
+
+	rlp, err := buildRateLimitPolicy(doc, opts)
+	problems.Append(err)
+
+	if err := problems.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
+	objects := make([]client.Object, 0, len(httpRoutes))
+	for _, httpRoute := range httpRoutes {
+		objects = append(objects, httpRoute)
+	}
+
+	for _, routeAP := range kuadrantapi.AuthPoliciesForHTTPRoutes(ap, httpRoutes) {
+		if kuadrantapi.AuthPolicyHasRules(routeAP) {
+			objects = append(objects, routeAP)
+		}
+	}
+
+	for _, routeRLP := range kuadrantapi.RateLimitPoliciesForHTTPRoutes(rlp, httpRoutes) {
+		if len(routeRLP.Spec.Limits) > 0 {
+			objects = append(objects, routeRLP)
+		}
+	}
+
+	return objects, nil
+}
+
+// printGeneratedObjects writes a single object as a YAML or JSON document,
+// and several objects as a multi-document YAML stream or a JSON List
+func printGeneratedObjects(cmd *cobra.Command, format string, objects []client.Object) error {
+	if len(objects) != 1 {
+		outputBytes, err := marshalBundle(format, objects)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(cmd.OutOrStdout(), string(outputBytes))
+		return nil
+	}
+
+	jsonBytes, err := json.Marshal(objects[0])
+	if err != nil {
+		return err
+	}
+
+	outputBytes := jsonBytes
+	if format != "json" {
+		outputBytes, err = yaml.JSONToYAML(jsonBytes) // use `omitempty`'s from the json Marshal
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), string(outputBytes))
+	return nil
+}
+
+// marshalBundle serializes the objects as a multi-document YAML stream or as a JSON List
+func marshalBundle(format string, objects []client.Object) ([]byte, error) {
+	if format == "json" {
+		list := metav1.List{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "List"},
+			Items:    make([]runtime.RawExtension, 0, len(objects)),
+		}
+
+		for _, obj := range objects {
+			jsonBytes, err := json.Marshal(obj)
+			if err != nil {
+				return nil, err
+			}
+			list.Items = append(list.Items, runtime.RawExtension{Raw: jsonBytes})
+		}
+
+		jsonBytes, err := json.Marshal(list)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(jsonBytes, '\n'), nil
+	}
+
+	var buf bytes.Buffer
+	for _, obj := range objects {
+		yamlBytes, err := marshalObject("yaml", obj)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(yamlBytes)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeBundleToDir writes every object in its own file named after the object kind and name
+func writeBundleToDir(dir, format string, objects []client.Object) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for _, obj := range objects {
+		outputBytes, err := marshalObject(format, obj)
+		if err != nil {
+			return err
+		}
+
+		filePath := filepath.Join(dir, bundleFileName(format, obj))
+		err = os.WriteFile(filePath, outputBytes, 0o644)
+		logf.Log.V(1).Info("Wrote resource to file", "file", filePath, "error", err)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func bundleFileName(format string, obj client.Object) string {
+	extension := "yaml"
+	if format == "json" {
+		extension = "json"
+	}
+
+	kind := strings.ToLower(obj.GetObjectKind().GroupVersionKind().Kind)
+	if obj.GetName() == "" {
+		return fmt.Sprintf("%s.%s", kind, extension)
+	}
+
+	return fmt.Sprintf("%s-%s.%s", kind, obj.GetName(), extension)
+}
+
+func marshalObject(format string, obj client.Object) ([]byte, error) {
+	jsonBytes, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "json" {
+		return append(jsonBytes, '\n'), nil
+	}
+
+	return yaml.JSONToYAML(jsonBytes) // use `omitempty`'s from the json Marshal
+}
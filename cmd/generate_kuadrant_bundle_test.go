@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	"sigs.k8s.io/yaml"
+
+	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+var _ = Describe("Generate Bundle", func() {
+	var (
+		cmd             *cobra.Command
+		cmdStdoutBuffer *bytes.Buffer
+		cmdStderrBuffer *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		cmd = generateKuadrantBundleCommand()
+		cmdStdoutBuffer = bytes.NewBufferString("")
+		cmdStderrBuffer = bytes.NewBufferString("")
+		cmd.SetOut(cmdStdoutBuffer)
+		cmd.SetErr(cmdStderrBuffer)
+	})
+
+	Context("with invalid OAS", func() {
+		It("happy path", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/invalid_oas.yaml"})
+			Expect(cmd.Execute()).Should(MatchError(ContainSubstring("OpenAPI validation error")))
+		})
+	})
+
+	Context("with invalid kuadrant extensions", func() {
+		It("all problems reported at once", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_invalid_kuadrant_extensions.yaml"})
+			err := cmd.Execute()
+			Expect(err).Should(HaveOccurred())
+
+			var problems utils.Problems
+			Expect(errors.As(err, &problems)).To(BeTrue())
+			Expect(problems).To(ConsistOf(
+				HaveField("Pointer", "#/x-kuadrant/route/name"),
+				HaveField("Pointer", "#/paths/~1cat/x-kuadrant"),
+			))
+		})
+	})
+
+	Context("with auth rules without security requirements", func() {
+		It("AuthPolicy generated", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_auth_rules_only.yaml"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			out, err := io.ReadAll(cmdStdoutBuffer)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			documents := strings.Split(strings.TrimPrefix(string(out), "---\n"), "---\n")
+			Expect(documents).To(HaveLen(2))
+
+			var kap kuadrantapiv1beta2.AuthPolicy
+			Expect(yaml.Unmarshal([]byte(documents[1]), &kap)).ShouldNot(HaveOccurred())
+			Expect(kap.TypeMeta.Kind).To(Equal("AuthPolicy"))
+			Expect(kap.Spec.AuthScheme.Authentication).To(BeEmpty())
+			Expect(kap.Spec.AuthScheme.Authorization).To(HaveKey("office-hours"))
+		})
+	})
+
+	Context("with security and rate limiting kuadrant extensions", func() {
+		It("multi-document YAML stream generated", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_openapi.yaml"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			out, err := io.ReadAll(cmdStdoutBuffer)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			documents := strings.Split(strings.TrimPrefix(string(out), "---\n"), "---\n")
+			Expect(documents).To(HaveLen(3))
+
+			var httpRoute gatewayapiv1.HTTPRoute
+			Expect(yaml.Unmarshal([]byte(documents[0]), &httpRoute)).ShouldNot(HaveOccurred())
+			Expect(httpRoute.TypeMeta.Kind).To(Equal("HTTPRoute"))
+			Expect(httpRoute.Name).To(Equal("petstore"))
+			Expect(httpRoute.Spec.Rules).To(HaveLen(3))
+
+			var kap kuadrantapiv1beta2.AuthPolicy
+			Expect(yaml.Unmarshal([]byte(documents[1]), &kap)).ShouldNot(HaveOccurred())
+			Expect(kap.TypeMeta.Kind).To(Equal("AuthPolicy"))
+			Expect(kap.Spec.AuthScheme.Authentication).To(HaveKey("securedDog"))
+
+			var rlp kuadrantapiv1beta2.RateLimitPolicy
+			Expect(yaml.Unmarshal([]byte(documents[2]), &rlp)).ShouldNot(HaveOccurred())
+			Expect(rlp.TypeMeta.Kind).To(Equal("RateLimitPolicy"))
+			Expect(rlp.Spec.Limits).To(HaveLen(2))
+		})
+
+		It("JSON List generated", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_openapi.yaml", "-o", "json"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			out, err := io.ReadAll(cmdStdoutBuffer)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			var list metav1.List
+			Expect(json.Unmarshal(out, &list)).ShouldNot(HaveOccurred())
+			Expect(list.Kind).To(Equal("List"))
+			Expect(list.Items).To(HaveLen(3))
+
+			kinds := make([]string, 0, len(list.Items))
+			for _, item := range list.Items {
+				var typeMeta metav1.TypeMeta
+				Expect(json.Unmarshal(item.Raw, &typeMeta)).ShouldNot(HaveOccurred())
+				kinds = append(kinds, typeMeta.Kind)
+			}
+			Expect(kinds).To(HaveExactElements("HTTPRoute", "AuthPolicy", "RateLimitPolicy"))
+		})
+
+		It("one file per resource written to the output directory", func() {
+			outputDir := filepath.Join(GinkgoT().TempDir(), "petstore")
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_openapi.yaml", "--output-dir", outputDir})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			Expect(cmdStdoutBuffer.Len()).To(BeZero())
+
+			entries, err := os.ReadDir(outputDir)
+			Expect(err).ShouldNot(HaveOccurred())
+			fileNames := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				fileNames = append(fileNames, entry.Name())
+			}
+			Expect(fileNames).To(ConsistOf(
+				"httproute-petstore.yaml",
+				"authpolicy-petstore.yaml",
+				"ratelimitpolicy-petstore.yaml",
+			))
+
+			data, err := os.ReadFile(filepath.Join(outputDir, "ratelimitpolicy-petstore.yaml"))
+			Expect(err).ShouldNot(HaveOccurred())
+			var rlp kuadrantapiv1beta2.RateLimitPolicy
+			Expect(yaml.Unmarshal(data, &rlp)).ShouldNot(HaveOccurred())
+			Expect(rlp.Spec.Limits).To(HaveLen(2))
+		})
+	})
+
+	Context("without security nor rate limiting kuadrant extensions", func() {
+		It("empty policies skipped", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_routes_only.yaml"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			out, err := io.ReadAll(cmdStdoutBuffer)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			documents := strings.Split(strings.TrimPrefix(string(out), "---\n"), "---\n")
+			Expect(documents).To(HaveLen(1))
+
+			var httpRoute gatewayapiv1.HTTPRoute
+			Expect(yaml.Unmarshal([]byte(documents[0]), &httpRoute)).ShouldNot(HaveOccurred())
+			Expect(httpRoute.TypeMeta.Kind).To(Equal("HTTPRoute"))
+			Expect(httpRoute.Spec.Rules).To(HaveLen(1))
+		})
+	})
+})
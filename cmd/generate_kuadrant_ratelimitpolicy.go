@@ -1,16 +1,14 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 
 	"github.com/getkin/kin-openapi/openapi3"
-	"github.com/ghodss/yaml"
-
 	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
@@ -19,23 +17,25 @@ import (
 	"github.com/kuadrant/kuadrantctl/pkg/utils"
 )
 
-//kuadrantctl generate kuadrant ratelimitpolicy --oas [OAS_FILE_PATH | OAS_URL | @]
+//kuadrantctl generate kuadrant ratelimitpolicy --oas [OAS_FILE_PATH | OAS_DIR | OAS_GLOB | OAS_URL | @]...
 
 var (
-	generateRateLimitPolicyOAS    string
+	generateRateLimitPolicyOAS    []string
 	generateRateLimitPolicyFormat string
 )
 
 func generateKuadrantRateLimitPolicyCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "ratelimitpolicy",
-		Short: "Generate Kuadrant Rate Limit Policy from OpenAPI 3.0.X",
-		Long:  "Generate Kuadrant Rate Limit Policy from OpenAPI 3.0.X",
+		Short: "Generate Kuadrant Rate Limit Policy from OpenAPI 3.0.X or 3.1.X",
+		Long:  "Generate Kuadrant Rate Limit Policy from OpenAPI 3.0.X or 3.1.X",
 		RunE:  runGenerateKuadrantRateLimitPolicy,
 	}
 
-	cmd.Flags().StringVar(&generateRateLimitPolicyOAS, "oas", "", "Path to OpenAPI spec file (in JSON or YAML format), URL, or '-' to read from standard input (required)")
+	cmd.Flags().StringArrayVar(&generateRateLimitPolicyOAS, "oas", nil, "Path to OpenAPI spec file (in JSON or YAML format), directory, glob pattern, URL, or '-' to read from standard input. Can be repeated to generate the resources of several documents (required)")
+	addOASReadFlags(cmd)
 	cmd.Flags().StringVarP(&generateRateLimitPolicyFormat, "output-format", "o", "yaml", "Output format: 'yaml' or 'json'.")
+	addGenerateOptionsFlags(cmd)
 
 	if err := cmd.MarkFlagRequired("oas"); err != nil {
 		fmt.Println("Error setting 'oas' flag as required:", err)
@@ -46,52 +46,66 @@ func generateKuadrantRateLimitPolicyCommand() *cobra.Command {
 }
 
 func runGenerateKuadrantRateLimitPolicy(cmd *cobra.Command, args []string) error {
-	oasDataRaw, err := utils.ReadExternalResource(generateRateLimitPolicyOAS)
-	if err != nil {
-		return err
-	}
+	objects, err := generateObjects(cmd, generateRateLimitPolicyOAS, func(doc *openapi3.T, opts *utils.GenerateOptions) ([]client.Object, error) {
+		policies, err := buildRateLimitPolicies(doc, opts)
+		if err != nil {
+			return nil, err
+		}
 
-	openapiLoader := openapi3.NewLoader()
-	doc, err := openapiLoader.LoadFromData(oasDataRaw)
+		objects := make([]client.Object, 0, len(policies))
+		for _, policy := range policies {
+			objects = append(objects, policy)
+		}
+		return objects, nil
+	})
 	if err != nil {
 		return err
 	}
 
-	err = doc.Validate(openapiLoader.Context)
+	return printGeneratedObjects(cmd, generateRateLimitPolicyFormat, objects)
+}
+
+// buildRateLimitPolicies returns one RateLimitPolicy per HTTPRoute, see buildHTTPRoutes
+func buildRateLimitPolicies(doc *openapi3.T, opts *utils.GenerateOptions) ([]*kuadrantapiv1beta2.RateLimitPolicy, error) {
+	rlp, err := buildRateLimitPolicy(doc, opts)
 	if err != nil {
-		return fmt.Errorf("OpenAPI validation error: %w", err)
+		return nil, err
 	}
 
-	rlp := buildRateLimitPolicy(doc)
-
-	jsonBytes, err := json.Marshal(rlp)
-	if err != nil {
-		return err
+	if !opts.GetCompactRules() {
+		return []*kuadrantapiv1beta2.RateLimitPolicy{rlp}, nil
 	}
 
-	var outputBytes []byte
-	if generateRateLimitPolicyFormat == "json" {
-		outputBytes = jsonBytes
-	} else {
-		outputBytes, err = yaml.JSONToYAML(jsonBytes) // use `omitempty`'s from the json Marshal
-		if err != nil {
-			return err
-		}
+	httpRoutes, err := buildHTTPRoutes(doc, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Fprintln(cmd.OutOrStdout(), string(outputBytes))
-	return nil
+	return kuadrantapi.RateLimitPoliciesForHTTPRoutes(rlp, httpRoutes), nil
 }
 
-func buildRateLimitPolicy(doc *openapi3.T) *kuadrantapiv1beta2.RateLimitPolicy {
-	routeMeta := gatewayapi.HTTPRouteObjectMetaFromOAS(doc)
+func buildRateLimitPolicy(doc *openapi3.T, opts *utils.GenerateOptions) (*kuadrantapiv1beta2.RateLimitPolicy, error) {
+	var problems utils.Problems
+
+	routeMeta, err := gatewayapi.HTTPRouteObjectMetaFromOAS(doc)
+	problems.Append(err)
+
+	objectMeta, err := kuadrantapi.RateLimitPolicyObjectMetaFromOAS(doc)
+	problems.Append(err)
+
+	limits, err := kuadrantapi.RateLimitPolicyLimitsFromOAS(doc, opts)
+	problems.Append(err)
+
+	if err := problems.ErrorOrNil(); err != nil {
+		return nil, err
+	}
 
 	rlp := &kuadrantapiv1beta2.RateLimitPolicy{
 		TypeMeta: v1.TypeMeta{
 			APIVersion: "kuadrant.io/v1beta2",
 			Kind:       "RateLimitPolicy",
 		},
-		ObjectMeta: kuadrantapi.RateLimitPolicyObjectMetaFromOAS(doc),
+		ObjectMeta: objectMeta,
 		Spec: kuadrantapiv1beta2.RateLimitPolicySpec{
 			TargetRef: gatewayapiv1alpha2.PolicyTargetReference{
 				Group: gatewayapiv1.GroupName,
@@ -99,7 +113,7 @@ func buildRateLimitPolicy(doc *openapi3.T) *kuadrantapiv1beta2.RateLimitPolicy {
 				Name:  gatewayapiv1.ObjectName(routeMeta.Name),
 			},
 			RateLimitPolicyCommonSpec: kuadrantapiv1beta2.RateLimitPolicyCommonSpec{
-				Limits: kuadrantapi.RateLimitPolicyLimitsFromOAS(doc),
+				Limits: limits,
 			},
 		},
 	}
@@ -110,5 +124,5 @@ func buildRateLimitPolicy(doc *openapi3.T) *kuadrantapiv1beta2.RateLimitPolicy {
 		}[0]
 	}
 
-	return rlp
+	return rlp, nil
 }
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
 	"io"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -14,6 +15,8 @@ import (
 	"sigs.k8s.io/yaml"
 
 	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
 )
 
 var _ = Describe("Generate Ratelimitpolicy", func() {
@@ -114,4 +117,71 @@ var _ = Describe("Generate Ratelimitpolicy", func() {
 			}))
 		})
 	})
+
+	Context("with rate limits shared by several operations", func() {
+		match := func(path string, method gatewayapiv1.HTTPMethod) gatewayapiv1.HTTPRouteMatch {
+			return gatewayapiv1.HTTPRouteMatch{
+				Path: &gatewayapiv1.HTTPPathMatch{
+					Type:  ptr.To(gatewayapiv1.PathMatchExact),
+					Value: ptr.To(path),
+				},
+				Method: ptr.To(method),
+			}
+		}
+
+		It("named rate limits shared", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_rate_limit_groups.yaml"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			out, err := io.ReadAll(cmdStdoutBuffer)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			var rlp kuadrantapiv1beta2.RateLimitPolicy
+			Expect(yaml.Unmarshal(out, &rlp)).ShouldNot(HaveOccurred())
+			Expect(rlp.Spec.Limits).To(HaveLen(4))
+			Expect(rlp.Spec.Limits["cats"].RouteSelectors).To(HaveExactElements(kuadrantapiv1beta2.RouteSelector{
+				Matches: []gatewayapiv1.HTTPRouteMatch{
+					match("/v1/cat", gatewayapiv1.HTTPMethodGet),
+					match("/v1/cat", gatewayapiv1.HTTPMethodPost),
+					match("/v1/dog", gatewayapiv1.HTTPMethodPost),
+				},
+			}))
+			Expect(rlp.Spec.Limits).To(HaveKey("getDog"))
+			Expect(rlp.Spec.Limits).To(HaveKey("getSnake"))
+		})
+
+		It("identical rate limits grouped on demand", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_rate_limit_groups.yaml", "--group-rate-limits"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			out, err := io.ReadAll(cmdStdoutBuffer)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			var rlp kuadrantapiv1beta2.RateLimitPolicy
+			Expect(yaml.Unmarshal(out, &rlp)).ShouldNot(HaveOccurred())
+			Expect(rlp.Spec.Limits).To(HaveLen(3))
+			Expect(rlp.Spec.Limits).To(HaveKey("cats"))
+			Expect(rlp.Spec.Limits).To(HaveKey("postSnake"))
+			Expect(rlp.Spec.Limits["getDog"].RouteSelectors).To(HaveExactElements(kuadrantapiv1beta2.RouteSelector{
+				Matches: []gatewayapiv1.HTTPRouteMatch{
+					match("/v1/dog", gatewayapiv1.HTTPMethodGet),
+					match("/v1/snake", gatewayapiv1.HTTPMethodGet),
+				},
+			}))
+		})
+
+		It("conflicting rate limits reported", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_rate_limit_groups_conflict.yaml"})
+			err := cmd.Execute()
+
+			var problems utils.Problems
+			Expect(errors.As(err, &problems)).To(BeTrue())
+			Expect(problems).To(ConsistOf(
+				utils.NewError("#/paths/~1dog/post/x-kuadrant/rate_limit",
+					`rate limit "cats" differs from the rate limit of the same name of another operation`),
+				utils.NewError("#/paths/~1bird/get/x-kuadrant/rate_limit",
+					`rate limit name "getSnake" clashes with the limit named after the operation "getSnake", whose rate limit has no name`),
+				utils.NewError("#/paths/~1zebra/get/x-kuadrant/rate_limit",
+					`rate limit name "getDog" clashes with the limit named after the operation "getDog", whose rate limit has no name`),
+			))
+		})
+	})
 })
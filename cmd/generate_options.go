@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+// Flags shared by the commands generating resources from OpenAPI
+var (
+	generateOperationOrder       string
+	generateServerIndex          int
+	generateServerURL            string
+	generateServerVariables      []string
+	generateHostnamesFromServers bool
+	generateCompactRules         bool
+	generatePathTemplateMatch    string
+	generateAuthPerOperation     bool
+	generateGroupRateLimits      bool
+)
+
+func addGenerateOptionsFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&generateOperationOrder, "operation-order", string(utils.OperationOrderAlphabetical), "Order of the generated rules, sorted by path and then by method: 'alphabetical' or 'spec' (as written in the OpenAPI document)")
+	cmd.Flags().IntVar(&generateServerIndex, "server-index", 0, "Index of the OpenAPI server the base path is read from (default the first server)")
+	cmd.Flags().StringVar(&generateServerURL, "server-url", "", "URL of the OpenAPI server the base path is read from, as written in the document or rendered. Mutually exclusive with --server-index")
+	cmd.Flags().StringArrayVar(&generateServerVariables, "server-var", nil, "Value of an OpenAPI server variable, name=value. Can be repeated")
+	cmd.Flags().BoolVar(&generateHostnamesFromServers, "hostnames-from-servers", false, "Fill the HTTPRoute hostnames from the hosts of the OpenAPI server URLs when the kuadrant extension does not define them. Enum server variables are expanded")
+	cmd.Flags().BoolVar(&generateCompactRules, "compact-rules", false, "Group operations with the same backendRefs and filters in HTTPRoute rules, and split the rules in several HTTPRoutes when the Gateway API limits are exceeded. Policies follow the HTTPRoutes")
+	cmd.Flags().StringVar(&generatePathTemplateMatch, "path-template-match", string(utils.PathTemplateMatchRegex), "Match of templated paths like /pets/{petId}: 'regex' (derived from the path parameter schemas) or 'prefix' (the path before the first templated segment, for implementations without regular expression support)")
+	cmd.Flags().BoolVar(&generateAuthPerOperation, "authentication-per-operation", false, "Generate one AuthPolicy authentication rule per operation and security scheme, instead of one rule per security scheme selecting every operation secured by the scheme")
+	cmd.Flags().BoolVar(&generateGroupRateLimits, "group-rate-limits", false, "Group the operations with identical rate limits in one RateLimitPolicy limit, named after the first operation and sharing the counters. Named rate limits are always grouped")
+	cmd.MarkFlagsMutuallyExclusive("server-index", "server-url")
+}
+
+// generateOptions builds the generator options from the shared flags.
+// Constructs ignored by the generators are reported as warnings to the command error output.
+func generateOptions(cmd *cobra.Command, specOrder *utils.SpecOrder) (*utils.GenerateOptions, error) {
+	var warnings utils.Problems
+
+	serverVariables, err := utils.ParseServerVariables(generateServerVariables)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &utils.GenerateOptions{
+		OperationOrder:             utils.OperationOrder(generateOperationOrder),
+		SpecOrder:                  specOrder,
+		ServerURL:                  generateServerURL,
+		ServerVariables:            serverVariables,
+		HostnamesFromServers:       generateHostnamesFromServers,
+		CompactRules:               generateCompactRules,
+		PathTemplateMatch:          utils.PathTemplateMatch(generatePathTemplateMatch),
+		AuthenticationPerOperation: generateAuthPerOperation,
+		GroupRateLimits:            generateGroupRateLimits,
+		WarningHandler: func(problem utils.Problem) {
+			if warnings.Contains(problem) {
+				return
+			}
+			warnings.Add(problem)
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s: %s\n", problem.Pointer, problem.Message)
+		},
+	}
+
+	if cmd.Flags().Changed("server-index") {
+		opts.ServerIndex = &generateServerIndex
+	}
+
+	if err := opts.OperationOrder.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := opts.PathTemplateMatch.Validate(); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"bytes"
+
+	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var _ = Describe("Generate with OpenAPI servers", func() {
+	var (
+		cmd             *cobra.Command
+		cmdStdoutBuffer *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		cmd = generateGatewayApiHttpRouteCommand()
+		cmdStdoutBuffer = bytes.NewBufferString("")
+		cmd.SetOut(cmdStdoutBuffer)
+		cmd.SetErr(bytes.NewBufferString(""))
+		cmd.SilenceUsage = true
+		// package level flag vars keep the value of previous runs
+		generateGatewayAPIHTTPRouteFormat = "yaml"
+	})
+
+	generate := func(args ...string) *gatewayapiv1.HTTPRoute {
+		cmd.SetArgs(append([]string{"--oas", "testdata/petstore_servers.yaml"}, args...))
+		Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+
+		var httpRoute gatewayapiv1.HTTPRoute
+		Expect(yaml.Unmarshal(cmdStdoutBuffer.Bytes(), &httpRoute)).ShouldNot(HaveOccurred())
+		Expect(httpRoute.Spec.Rules).To(HaveLen(1))
+		Expect(httpRoute.Spec.Rules[0].Matches).To(HaveLen(1))
+		return &httpRoute
+	}
+
+	matchPath := func(httpRoute *gatewayapiv1.HTTPRoute) string {
+		return *httpRoute.Spec.Rules[0].Matches[0].Path.Value
+	}
+
+	It("first server by default", func() {
+		httpRoute := generate()
+		Expect(matchPath(httpRoute)).To(Equal("/v1/cat"))
+		Expect(httpRoute.Spec.Hostnames).To(BeEmpty())
+	})
+
+	It("server selected by index", func() {
+		Expect(matchPath(generate("--server-index", "1"))).To(Equal("/v2/cat"))
+	})
+
+	It("server selected by URL", func() {
+		Expect(matchPath(generate("--server-url", "https://{region}.example.com:{port}/{version}"))).To(Equal("/v2/cat"))
+	})
+
+	It("server selected by rendered URL", func() {
+		Expect(matchPath(generate("--server-url", "https://us.example.com:443/v3", "--server-var", "region=us", "--server-var", "version=v3"))).To(Equal("/v3/cat"))
+	})
+
+	It("server variables override the defaults", func() {
+		Expect(matchPath(generate("--server-index", "1", "--server-var", "version=v3"))).To(Equal("/v3/cat"))
+	})
+
+	It("server variable value not in the enum", func() {
+		cmd.SetArgs([]string{"--oas", "testdata/petstore_servers.yaml", "--server-index", "1", "--server-var", "version=v4"})
+		Expect(cmd.Execute()).Should(MatchError(ContainSubstring(
+			`#/servers/1/variables/version/enum: server variable "version" value "v4" not allowed, valid values: [v2 v3]`,
+		)))
+	})
+
+	It("server variable not defined", func() {
+		cmd.SetArgs([]string{"--oas", "testdata/petstore_servers.yaml", "--server-var", "version=v3"})
+		Expect(cmd.Execute()).Should(MatchError(ContainSubstring(`server variable "version" not defined`)))
+	})
+
+	It("server index out of range", func() {
+		cmd.SetArgs([]string{"--oas", "testdata/petstore_servers.yaml", "--server-index", "2"})
+		Expect(cmd.Execute()).Should(MatchError(ContainSubstring("server index 2 out of range, 2 servers found")))
+	})
+
+	It("server index and server URL are mutually exclusive", func() {
+		cmd.SetArgs([]string{"--oas", "testdata/petstore_servers.yaml", "--server-index", "1", "--server-url", "https://staging.example.io/v1"})
+		Expect(cmd.Execute()).Should(HaveOccurred())
+	})
+
+	It("hostnames from every server, enum variables expanded", func() {
+		httpRoute := generate("--hostnames-from-servers")
+		Expect(httpRoute.Spec.Hostnames).To(HaveExactElements(
+			gatewayapiv1.Hostname("staging.example.io"),
+			gatewayapiv1.Hostname("eu.example.com"),
+			gatewayapiv1.Hostname("us.example.com"),
+		))
+	})
+
+	It("hostnames from the selected server and variables", func() {
+		httpRoute := generate("--hostnames-from-servers", "--server-index", "1", "--server-var", "region=us")
+		Expect(httpRoute.Spec.Hostnames).To(HaveExactElements(gatewayapiv1.Hostname("us.example.com")))
+	})
+
+	It("kuadrant extension hostnames take precedence", func() {
+		cmd.SetArgs([]string{"--oas", "testdata/petstore_openapi.yaml", "--hostnames-from-servers"})
+		Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+
+		var httpRoute gatewayapiv1.HTTPRoute
+		Expect(yaml.Unmarshal(cmdStdoutBuffer.Bytes(), &httpRoute)).ShouldNot(HaveOccurred())
+		Expect(httpRoute.Spec.Hostnames).To(HaveExactElements(gatewayapiv1.Hostname("example.com")))
+	})
+})
+
+var _ = Describe("Generate with path and operation level servers", func() {
+	// matchPaths returns the path values of the matches, in order
+	matchPaths := func(matches []gatewayapiv1.HTTPRouteMatch) []string {
+		paths := make([]string, 0, len(matches))
+		for _, match := range matches {
+			paths = append(paths, *match.Path.Value)
+		}
+		return paths
+	}
+
+	run := func(newCommand func() *cobra.Command, out any, args ...string) error {
+		cmd := newCommand()
+		cmdStdoutBuffer := bytes.NewBufferString("")
+		cmd.SetOut(cmdStdoutBuffer)
+		cmd.SetErr(bytes.NewBufferString(""))
+		cmd.SilenceUsage = true
+		cmd.SetArgs(append([]string{"--oas", "testdata/petstore_servers_overrides.yaml", "-o", "yaml"}, args...))
+		if err := cmd.Execute(); err != nil {
+			return err
+		}
+		return yaml.Unmarshal(cmdStdoutBuffer.Bytes(), out)
+	}
+
+	It("HTTPRoute matches use the base path of the operation servers", func() {
+		var httpRoute gatewayapiv1.HTTPRoute
+		Expect(run(generateGatewayApiHttpRouteCommand, &httpRoute)).To(Succeed())
+
+		var paths []string
+		for _, rule := range httpRoute.Spec.Rules {
+			paths = append(paths, matchPaths(rule.Matches)...)
+		}
+		Expect(paths).To(HaveExactElements("/v1/cat", "/internal/stats", "/admin/v2/stats"))
+	})
+
+	It("path level server variables overridden", func() {
+		var httpRoute gatewayapiv1.HTTPRoute
+		Expect(run(generateGatewayApiHttpRouteCommand, &httpRoute, "--server-var", "version=v3")).To(Succeed())
+		Expect(matchPaths(httpRoute.Spec.Rules[2].Matches)).To(HaveExactElements("/admin/v3/stats"))
+	})
+
+	It("path level server variable value not in the enum", func() {
+		var httpRoute gatewayapiv1.HTTPRoute
+		Expect(run(generateGatewayApiHttpRouteCommand, &httpRoute, "--server-var", "version=v4")).Should(MatchError(ContainSubstring(
+			`#/paths/~1stats/servers/0/variables/version/enum: server variable "version" value "v4" not allowed, valid values: [v2 v3]`,
+		)))
+	})
+
+	It("hostnames from the document, path and operation servers", func() {
+		var httpRoute gatewayapiv1.HTTPRoute
+		Expect(run(generateGatewayApiHttpRouteCommand, &httpRoute, "--hostnames-from-servers")).To(Succeed())
+		Expect(httpRoute.Spec.Hostnames).To(HaveExactElements(
+			gatewayapiv1.Hostname("petstore.example.io"),
+			gatewayapiv1.Hostname("internal.example.io"),
+			gatewayapiv1.Hostname("admin.example.io"),
+		))
+	})
+
+	It("AuthPolicy route selectors use the base path of the operation servers", func() {
+		var kap kuadrantapiv1beta2.AuthPolicy
+		Expect(run(generateKuadrantAuthPolicyCommand, &kap)).To(Succeed())
+
+		var paths []string
+		for _, routeSelector := range kap.Spec.AuthPolicyCommonSpec.RouteSelectors {
+			paths = append(paths, matchPaths(routeSelector.Matches)...)
+		}
+		Expect(paths).To(HaveExactElements("/v1/cat", "/internal/stats", "/admin/v2/stats"))
+	})
+
+	It("RateLimitPolicy route selectors use the base path of the operation servers", func() {
+		var rlp kuadrantapiv1beta2.RateLimitPolicy
+		Expect(run(generateKuadrantRateLimitPolicyCommand, &rlp)).To(Succeed())
+		Expect(rlp.Spec.Limits).To(HaveLen(3))
+		Expect(matchPaths(rlp.Spec.Limits["getCat"].RouteSelectors[0].Matches)).To(HaveExactElements("/v1/cat"))
+		Expect(matchPaths(rlp.Spec.Limits["deleteStats"].RouteSelectors[0].Matches)).To(HaveExactElements("/internal/stats"))
+		Expect(matchPaths(rlp.Spec.Limits["getStats"].RouteSelectors[0].Matches)).To(HaveExactElements("/admin/v2/stats"))
+	})
+})
@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+var (
+	lintOAS    string
+	lintFormat string
+	lintFailOn string
+)
+
+//kuadrantctl lint --oas [OAS_FILE_PATH | OAS_URL | @]
+
+func lintCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Validate the Kuadrant extensions of an OpenAPI 3.0.X or 3.1.X document",
+		Long: `Validate the Kuadrant extensions of an OpenAPI 3.0.X or 3.1.X document.
+Every x-kuadrant block is checked against the extension schema: unknown fields,
+values of the wrong type and invalid enum values are reported as errors.
+Constructs ignored by the generators are reported as warnings.`,
+		RunE: runLint,
+	}
+
+	cmd.Flags().StringVar(&lintOAS, "oas", "", "Path to OpenAPI spec file (in JSON or YAML format), URL, or '-' to read from standard input (required)")
+	addOASReadFlags(cmd)
+	cmd.Flags().StringVarP(&lintFormat, "output-format", "o", "text", "Output format: 'text', 'json' or 'sarif'.")
+	cmd.Flags().StringVar(&lintFailOn, "fail-on", string(utils.SeverityError), "Minimum severity making the command exit with non-zero status: 'error' or 'warning'.")
+	addGenerateOptionsFlags(cmd)
+	err := cmd.MarkFlagRequired("oas")
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	if lintFailOn != string(utils.SeverityError) && lintFailOn != string(utils.SeverityWarning) {
+		return fmt.Errorf("unknown severity %q for --fail-on, valid values: [error warning]", lintFailOn)
+	}
+
+	reader := oasResourceReader(cmd, lintOAS)
+	oasDataRaw, err := reader.Read(lintOAS)
+	if err != nil {
+		return err
+	}
+
+	location, err := reader.Location(lintOAS)
+	if err != nil {
+		return err
+	}
+
+	doc, specOrder, err := loadOpenAPIData(oasDataRaw, location, reader)
+	if err != nil {
+		return err
+	}
+
+	opts, err := generateOptions(cmd, specOrder)
+	if err != nil {
+		return err
+	}
+
+	problems := utils.ValidateKuadrantOASExtensions(doc)
+
+	// Semantic issues are the ones found by the generators
+	var generatorProblems utils.Problems
+	opts.WarningHandler = func(problem utils.Problem) { generatorProblems.Add(problem) }
+	_, err = buildBundle(doc, opts)
+	generatorProblems.Append(err)
+
+	for _, problem := range generatorProblems {
+		if !lintReportedWithin(problems, problem) {
+			problems.Add(problem)
+		}
+	}
+
+	sourceMap, err := utils.NewSourceMap(oasDataRaw)
+	if err != nil {
+		return err
+	}
+
+	switch lintFormat {
+	case "json":
+		err = writeLintJSON(cmd.OutOrStdout(), problems)
+	case "sarif":
+		err = writeLintSARIF(cmd.OutOrStdout(), lintArtifactURI(lintOAS), sourceMap, problems)
+	default:
+		err = writeLintText(cmd.OutOrStdout(), lintArtifactURI(lintOAS), sourceMap, problems)
+	}
+	if err != nil {
+		return err
+	}
+
+	if problems.HasErrors() || (lintFailOn == string(utils.SeverityWarning) && len(problems) > 0) {
+		return fmt.Errorf("lint failed: %d problems found", len(problems))
+	}
+
+	return nil
+}
+
+// lintReportedWithin returns true when a more specific error has been already reported
+// for the location of the problem. For instance, the unknown field of a kuadrant extension
+// that the generators fail to decode as a whole.
+func lintReportedWithin(problems utils.Problems, problem utils.Problem) bool {
+	for _, reported := range problems {
+		if reported.Severity == utils.SeverityError && strings.HasPrefix(reported.Pointer, problem.Pointer+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func lintArtifactURI(oasResource string) string {
+	if oasResource == "-" || oasResource == "@" {
+		return "stdin"
+	}
+
+	return oasResource
+}
+
+func writeLintText(w io.Writer, artifactURI string, sourceMap *utils.SourceMap, problems utils.Problems) error {
+	if len(problems) == 0 {
+		_, err := fmt.Fprintln(w, "no problems found")
+		return err
+	}
+
+	for _, problem := range problems {
+		_, err := fmt.Fprintf(w, "%s:%d: %s\n", artifactURI, sourceMap.Line(problem.Pointer), problem.Error())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeLintJSON(w io.Writer, problems utils.Problems) error {
+	if problems == nil {
+		problems = utils.Problems{}
+	}
+
+	jsonBytes, err := json.MarshalIndent(problems, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(jsonBytes))
+	return err
+}
+
+// SARIF 2.1.0 log format subset
+// Ref https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+var sarifRules = map[utils.Severity]sarifRule{
+	utils.SeverityError: {
+		ID:               "kuadrant-oas-error",
+		ShortDescription: sarifMessage{Text: "The OpenAPI document cannot be translated into Kuadrant resources"},
+	},
+	utils.SeverityWarning: {
+		ID:               "kuadrant-oas-warning",
+		ShortDescription: sarifMessage{Text: "The OpenAPI document contains constructs ignored by kuadrantctl"},
+	},
+}
+
+func writeLintSARIF(w io.Writer, artifactURI string, sourceMap *utils.SourceMap, problems utils.Problems) error {
+	results := make([]sarifResult, 0, len(problems))
+	for _, problem := range problems {
+		physicalLocation := sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: artifactURI},
+		}
+		if line := sourceMap.Line(problem.Pointer); line > 0 {
+			physicalLocation.Region = &sarifRegion{StartLine: line}
+		}
+
+		results = append(results, sarifResult{
+			RuleID:  sarifRules[problem.Severity].ID,
+			Level:   string(problem.Severity),
+			Message: sarifMessage{Text: problem.Message},
+			Locations: []sarifLocation{
+				{
+					PhysicalLocation: physicalLocation,
+					LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: problem.Pointer}},
+				},
+			},
+		})
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           "kuadrantctl",
+						Version:        version,
+						InformationURI: "https://github.com/Kuadrant/kuadrantctl",
+						Rules:          []sarifRule{sarifRules[utils.SeverityError], sarifRules[utils.SeverityWarning]},
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+
+	jsonBytes, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(jsonBytes))
+	return err
+}
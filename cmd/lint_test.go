@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+var _ = Describe("Lint", func() {
+	var (
+		cmd             *cobra.Command
+		cmdStdoutBuffer *bytes.Buffer
+		cmdStderrBuffer *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		cmd = lintCommand()
+		cmdStdoutBuffer = bytes.NewBufferString("")
+		cmdStderrBuffer = bytes.NewBufferString("")
+		cmd.SetOut(cmdStdoutBuffer)
+		cmd.SetErr(cmdStderrBuffer)
+		// as set by the root command
+		cmd.SilenceUsage = true
+		// package level flag vars keep the value of previous runs
+		lintFormat = "text"
+		lintFailOn = string(utils.SeverityError)
+	})
+
+	Context("with invalid OAS", func() {
+		It("happy path", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/invalid_oas.yaml"})
+			Expect(cmd.Execute()).Should(MatchError(ContainSubstring("OpenAPI validation error")))
+		})
+	})
+
+	Context("with valid kuadrant extensions", func() {
+		It("no problems found", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_routes_only.yaml"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			Expect(cmdStdoutBuffer.String()).To(Equal("no problems found\n"))
+		})
+	})
+
+	Context("with invalid kuadrant extensions", func() {
+		It("text output locates every problem", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_lint.yaml"})
+			Expect(cmd.Execute()).Should(MatchError("lint failed: 6 problems found"))
+
+			Expect(cmdStdoutBuffer.String()).To(Equal(
+				`testdata/petstore_lint.yaml:6: error #/x-kuadrant/route: unknown field "hostname"
+testdata/petstore_lint.yaml:14: error #/paths/~1cat/x-kuadrant/pathMatchType: invalid value "Prefix": valid values: [Exact PathPrefix RegularExpression]
+testdata/petstore_lint.yaml:12: error #/paths/~1cat/x-kuadrant: unknown field "pathMatchtype"
+testdata/petstore_lint.yaml:12: error #/paths/~1cat/x-kuadrant: unknown field "ratelimit"
+testdata/petstore_lint.yaml:21: error #/paths/~1cat/get/x-kuadrant/rate_limit/rates/0/limit: invalid value "1": expected integer
+testdata/petstore_lint.yaml:23: error #/paths/~1cat/get/x-kuadrant/rate_limit/rates/0/unit: invalid value "seconds": valid values: [second minute hour day]
+`))
+		})
+
+		It("json output", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_lint.yaml", "-o", "json"})
+			Expect(cmd.Execute()).Should(HaveOccurred())
+
+			var problems utils.Problems
+			Expect(json.Unmarshal(cmdStdoutBuffer.Bytes(), &problems)).To(Succeed())
+			Expect(problems).To(HaveLen(6))
+			Expect(problems).To(ContainElement(utils.NewError(
+				"#/paths/~1cat/x-kuadrant", `unknown field "ratelimit"`,
+			)))
+		})
+
+		It("sarif output", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_lint.yaml", "-o", "sarif"})
+			Expect(cmd.Execute()).Should(HaveOccurred())
+
+			var log sarifLog
+			Expect(json.Unmarshal(cmdStdoutBuffer.Bytes(), &log)).To(Succeed())
+			Expect(log.Version).To(Equal("2.1.0"))
+			Expect(log.Runs).To(HaveLen(1))
+			Expect(log.Runs[0].Results).To(HaveLen(6))
+
+			result := log.Runs[0].Results[0]
+			Expect(result.RuleID).To(Equal("kuadrant-oas-error"))
+			Expect(result.Level).To(Equal("error"))
+			Expect(result.Message.Text).To(Equal(`unknown field "hostname"`))
+			Expect(result.Locations).To(HaveLen(1))
+			Expect(result.Locations[0].PhysicalLocation.ArtifactLocation.URI).To(Equal("testdata/petstore_lint.yaml"))
+			Expect(result.Locations[0].PhysicalLocation.Region).To(Equal(&sarifRegion{StartLine: 6}))
+			Expect(result.Locations[0].LogicalLocations).To(HaveExactElements(
+				sarifLogicalLocation{FullyQualifiedName: "#/x-kuadrant/route"},
+			))
+		})
+	})
+
+	Context("with constructs ignored by the generators", func() {
+		It("warnings do not fail by default", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_lint_warnings.yaml"})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			Expect(cmdStdoutBuffer.String()).To(Equal(
+				`testdata/petstore_lint_warnings.yaml:27: warning #/components/securitySchemes/digest/scheme: http security scheme "digest" not supported, ignored by the AuthPolicy generator
+`))
+		})
+
+		It("warnings fail with --fail-on warning", func() {
+			cmd.SetArgs([]string{"--oas", "testdata/petstore_lint_warnings.yaml", "--fail-on", "warning"})
+			Expect(cmd.Execute()).Should(MatchError("lint failed: 1 problems found"))
+		})
+	})
+
+	It("unknown --fail-on severity is rejected", func() {
+		cmd.SetArgs([]string{"--oas", "testdata/petstore_lint.yaml", "--fail-on", "info"})
+		Expect(cmd.Execute()).Should(MatchError(ContainSubstring(`unknown severity "info" for --fail-on`)))
+	})
+})
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+var (
+	oasBundleOAS    string
+	oasBundleFormat string
+)
+
+func oasCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "oas",
+		Short: "Commands related to OpenAPI documents",
+		Long:  "Commands related to OpenAPI documents",
+	}
+
+	cmd.AddCommand(oasBundleCommand())
+
+	return cmd
+}
+
+//kuadrantctl oas bundle --oas [OAS_FILE_PATH | OAS_URL | @] [-o json|yaml]
+
+func oasBundleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Print the OpenAPI document with its external references resolved",
+		Long: `Print the OpenAPI document with its external references resolved, as read by the generate commands.
+The external references are replaced by the values they resolve to, references within the document are kept.
+OpenAPI 3.1 and Swagger 2.0 documents are printed in their OpenAPI 3.0 form.`,
+		RunE: runOASBundle,
+	}
+
+	cmd.Flags().StringVar(&oasBundleOAS, "oas", "", "Path to OpenAPI spec file (in JSON or YAML format), URL, or '-' to read from standard input (required)")
+	addOASReadFlags(cmd)
+	cmd.Flags().StringVarP(&oasBundleFormat, "output-format", "o", "yaml", "Output format: 'yaml' or 'json'.")
+	err := cmd.MarkFlagRequired("oas")
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func runOASBundle(cmd *cobra.Command, args []string) error {
+	doc, _, err := loadOpenAPIDocument(oasBundleOAS, oasResourceReader(cmd, oasBundleOAS))
+	if err != nil {
+		return err
+	}
+
+	if err := utils.BundleOpenAPIDocument(doc); err != nil {
+		return err
+	}
+
+	outputBytes, err := doc.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	if oasBundleFormat != "json" {
+		outputBytes, err = yaml.JSONToYAML(outputBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprint(cmd.OutOrStdout(), string(outputBytes))
+	return nil
+}
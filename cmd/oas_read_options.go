@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+// Environment variables holding the credentials of the remote OpenAPI documents,
+// when not set by flags
+const (
+	oasTokenEnvVar    = "KUADRANTCTL_OAS_TOKEN"
+	oasUsernameEnvVar = "KUADRANTCTL_OAS_USERNAME"
+	oasPasswordEnvVar = "KUADRANTCTL_OAS_PASSWORD"
+)
+
+// Flags shared by the commands reading OpenAPI documents
+var (
+	oasToken                 string
+	oasUsername              string
+	oasPassword              string
+	oasCredentialsHost       string
+	oasCAFile                string
+	oasInsecureSkipTLSVerify bool
+	oasTimeout               time.Duration
+	oasMaxSize               int64
+	oasRetries               int
+	oasCacheDir              string
+)
+
+func addOASReadFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&oasToken, "oas-token", "", "Bearer token sent when reading the OpenAPI document from a URL (default $"+oasTokenEnvVar+")")
+	cmd.Flags().StringVar(&oasUsername, "oas-username", "", "Basic authentication username sent when reading the OpenAPI document from a URL (default $"+oasUsernameEnvVar+")")
+	cmd.Flags().StringVar(&oasPassword, "oas-password", "", "Basic authentication password sent when reading the OpenAPI document from a URL (default $"+oasPasswordEnvVar+")")
+	cmd.Flags().StringVar(&oasCredentialsHost, "oas-credentials-host", "", "Host the credentials are sent to when reading the OpenAPI document and its external references (default the host of the OpenAPI document URL)")
+	cmd.Flags().StringVar(&oasCAFile, "oas-ca-file", "", "PEM bundle of the certificate authorities trusted, besides the system ones, when reading the OpenAPI document from a URL")
+	cmd.Flags().BoolVar(&oasInsecureSkipTLSVerify, "oas-insecure-skip-tls-verify", false, "Skip the verification of the server certificate when reading the OpenAPI document from a URL")
+	cmd.Flags().DurationVar(&oasTimeout, "oas-timeout", utils.DefaultHTTPTimeout, "Timeout of every request reading the OpenAPI document from a URL")
+	cmd.Flags().Int64Var(&oasMaxSize, "oas-max-size", utils.DefaultHTTPMaxSize, "Maximum size, in bytes, of the OpenAPI document read from a URL")
+	cmd.Flags().IntVar(&oasRetries, "oas-retries", utils.DefaultHTTPRetries, "Retries of the requests reading the OpenAPI document from a URL, on network errors and 429 or 5xx statuses")
+	cmd.Flags().StringVar(&oasCacheDir, "oas-cache-dir", "", "Directory caching the OpenAPI documents read from URLs, revalidated with their ETag. Disabled by default")
+}
+
+// oasHTTPOptions builds the options reading the OpenAPI document from the shared flags.
+// The credentials are only sent to the credentials host, by default the host of the OpenAPI document URL:
+// without credentials host, as for local documents, the credentials are not sent.
+func oasHTTPOptions(oasResource string) *utils.HTTPOptions {
+	opts := &utils.HTTPOptions{
+		BearerToken:           flagOrEnv(oasToken, oasTokenEnvVar),
+		Username:              flagOrEnv(oasUsername, oasUsernameEnvVar),
+		Password:              flagOrEnv(oasPassword, oasPasswordEnvVar),
+		CredentialsHost:       oasCredentialsHost,
+		CAFile:                oasCAFile,
+		InsecureSkipTLSVerify: oasInsecureSkipTLSVerify,
+		Timeout:               oasTimeout,
+		MaxSize:               oasMaxSize,
+		Retries:               &oasRetries,
+		CacheDir:              oasCacheDir,
+	}
+
+	if location, isURL := utils.ParseURL(oasResource); isURL && opts.CredentialsHost == "" {
+		opts.CredentialsHost = location.Host
+	}
+
+	return opts
+}
+
+// oasResourceReader returns the reader of the OpenAPI document and its external references.
+// ConfigMap and Secret keys are read from the cluster of the current kubeconfig context.
+func oasResourceReader(cmd *cobra.Command, oasResource string) *utils.ExternalResourceReader {
+	reader := utils.NewExternalResourceReader(oasHTTPOptions(oasResource))
+
+	getClient := sync.OnceValues(func() (client.Reader, error) {
+		configuration, err := config.GetConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		return client.New(configuration, client.Options{Scheme: scheme.Scheme})
+	})
+	reader.Register(utils.ConfigMapScheme, utils.ConfigMapResourceReader(cmd.Context(), getClient))
+	reader.Register(utils.SecretScheme, utils.SecretResourceReader(cmd.Context(), getClient))
+
+	return reader
+}
+
+func flagOrEnv(flagValue, envVar string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+
+	return os.Getenv(envVar)
+}
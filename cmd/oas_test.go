@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	"sigs.k8s.io/yaml"
+
+	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+)
+
+var _ = Describe("Multi-file OpenAPI documents", func() {
+	const oas = "testdata/multi_file/petstore.yaml"
+
+	It("external references resolved relative to the document", func() {
+		cmd := generateKuadrantAuthPolicyCommand()
+		cmdStdoutBuffer := bytes.NewBufferString("")
+		cmd.SetOut(cmdStdoutBuffer)
+		cmd.SetErr(bytes.NewBufferString(""))
+		cmd.SetArgs([]string{"--oas", oas})
+		Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+
+		var kap kuadrantapiv1beta2.AuthPolicy
+		Expect(yaml.Unmarshal(cmdStdoutBuffer.Bytes(), &kap)).ShouldNot(HaveOccurred())
+		Expect(kap.Spec.AuthScheme.Authentication["apiKeyPets"].Credentials.CustomHeader.Name).To(Equal("api_key"))
+		// path parameter schema read from the referenced file
+		Expect(*kap.Spec.RouteSelectors[0].Matches[0].Path.Value).To(Equal(`^/v1/cat/-?[0-9]+$`))
+	})
+
+	It("kuadrant extensions of external path items read", func() {
+		cmd := generateGatewayApiHttpRouteCommand()
+		cmdStdoutBuffer := bytes.NewBufferString("")
+		cmd.SetOut(cmdStdoutBuffer)
+		cmd.SetErr(bytes.NewBufferString(""))
+		cmd.SetArgs([]string{"--oas", oas})
+		Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+
+		var httpRoute gatewayapiv1.HTTPRoute
+		Expect(yaml.Unmarshal(cmdStdoutBuffer.Bytes(), &httpRoute)).ShouldNot(HaveOccurred())
+		Expect(httpRoute.Spec.Rules).To(HaveLen(2))
+		Expect(string(httpRoute.Spec.Rules[1].BackendRefs[0].Name)).To(Equal("dogstore"))
+	})
+
+	It("bundle without external references", func() {
+		cmd := oasBundleCommand()
+		cmdStdoutBuffer := bytes.NewBufferString("")
+		cmd.SetOut(cmdStdoutBuffer)
+		cmd.SetArgs([]string{"--oas", oas})
+		Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+		Expect(cmdStdoutBuffer.String()).ToNot(ContainSubstring("$ref"))
+
+		doc, err := openapi3.NewLoader().LoadFromData(cmdStdoutBuffer.Bytes())
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(doc.Components.SecuritySchemes["apiKeyPets"].Value.Name).To(Equal("api_key"))
+		schema := doc.Paths.Find("/cat/{catId}").Parameters[0].Value.Schema.Value
+		Expect(schema.Type).To(Equal("integer"))
+		Expect(*schema.Min).To(Equal(1.0))
+		Expect(doc.Paths.Find("/dog").Extensions).To(HaveKey("x-kuadrant"))
+	})
+})
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+// loadOpenAPIDocument reads, parses and validates the OpenAPI document
+// referenced by the --oas flag value.
+// The position of paths and operations in the document is returned as well.
+func loadOpenAPIDocument(oasResource string, reader *utils.ExternalResourceReader) (*openapi3.T, *utils.SpecOrder, error) {
+	oasDataRaw, err := reader.Read(oasResource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	location, err := reader.Location(oasResource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return loadOpenAPIData(oasDataRaw, location, reader)
+}
+
+// loadOpenAPIData parses and validates the raw OpenAPI document.
+// External references are resolved relative to the location of the document, when known,
+// and read with the external resource reader. The references allowed depend on the location, see utils.CheckExternalReference.
+// OpenAPI 3.1 documents are loaded in their OpenAPI 3.0 form.
+// Swagger 2.0 documents are converted to OpenAPI 3.0.
+func loadOpenAPIData(oasDataRaw []byte, location *url.URL, reader *utils.ExternalResourceReader) (*openapi3.T, *utils.SpecOrder, error) {
+	version, err := utils.OpenAPIVersionFromData(oasDataRaw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	oasData := oasDataRaw
+	switch version {
+	case utils.OpenAPIVersion31:
+		oasData, err = utils.OpenAPI31To30(oasDataRaw)
+	case utils.OpenAPIVersion20:
+		oasData, err = utils.Swagger2ToOpenAPI3(oasDataRaw)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	openapiLoader := openapi3.NewLoader()
+	openapiLoader.IsExternalRefsAllowed = true
+	openapiLoader.ReadFromURIFunc = func(_ *openapi3.Loader, refLocation *url.URL) ([]byte, error) {
+		// references are checked against the document of the --oas flag, nested references included
+		if err := utils.CheckExternalReference(location, refLocation); err != nil {
+			return nil, err
+		}
+		return reader.ReadLocation(refLocation)
+	}
+
+	var doc *openapi3.T
+	if location != nil {
+		doc, err = openapiLoader.LoadFromDataWithPath(oasData, location)
+	} else {
+		doc, err = openapiLoader.LoadFromData(oasData)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = validateOpenAPIDocument(openapiLoader, doc, version)
+	if err != nil {
+		return nil, nil, fmt.Errorf("OpenAPI validation error: %w", err)
+	}
+
+	specOrder, err := utils.NewSpecOrder(oasDataRaw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return doc, specOrder, nil
+}
+
+// validateOpenAPIDocument validates the document against the OpenAPI 3.0 specification.
+// For OpenAPI 3.1 documents, the summary and description siblings of references are allowed
+// and the mutualTLS security schemes, unknown to the 3.0 validation, are skipped.
+func validateOpenAPIDocument(openapiLoader *openapi3.Loader, doc *openapi3.T, version string) error {
+	if version != utils.OpenAPIVersion31 {
+		return doc.Validate(openapiLoader.Context)
+	}
+
+	if doc.Components != nil {
+		mutualTLSSchemes := openapi3.SecuritySchemes{}
+		for name, secScheme := range doc.Components.SecuritySchemes {
+			if secScheme != nil && secScheme.Value != nil && secScheme.Value.Type == "mutualTLS" {
+				mutualTLSSchemes[name] = secScheme
+				delete(doc.Components.SecuritySchemes, name)
+			}
+		}
+
+		defer func() {
+			for name, secScheme := range mutualTLSSchemes {
+				doc.Components.SecuritySchemes[name] = secScheme
+			}
+		}()
+	}
+
+	return doc.Validate(openapiLoader.Context, openapi3.AllowExtraSiblingFields("summary", "description"))
+}
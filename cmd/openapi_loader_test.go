@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+var _ = DescribeTable("OpenAPI 3.1 and Swagger 2.0 documents generate the same resources as 3.0",
+	func(newCommand func() *cobra.Command, oas30, oas string) {
+		run := func(oas string) string {
+			cmd := newCommand()
+			cmdStdoutBuffer := bytes.NewBufferString("")
+			cmd.SetOut(cmdStdoutBuffer)
+			cmd.SetErr(bytes.NewBufferString(""))
+			cmd.SetArgs([]string{"--oas", oas})
+			Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+			return cmdStdoutBuffer.String()
+		}
+
+		Expect(run(oas)).To(Equal(run(oas30)))
+	},
+	Entry("HTTPRoute with rate limits", generateGatewayApiHttpRouteCommand,
+		"../examples/oas3/petstore-with-rate-limit-kuadrant-extensions.yaml", "testdata/petstore_rate_limit_3_1.yaml"),
+	Entry("RateLimitPolicy", generateKuadrantRateLimitPolicyCommand,
+		"../examples/oas3/petstore-with-rate-limit-kuadrant-extensions.yaml", "testdata/petstore_rate_limit_3_1.yaml"),
+	Entry("HTTPRoute with oidc", generateGatewayApiHttpRouteCommand,
+		"../examples/oas3/petstore-with-oidc-kuadrant-extensions.yaml", "testdata/petstore_oidc_3_1.yaml"),
+	Entry("AuthPolicy", generateKuadrantAuthPolicyCommand,
+		"../examples/oas3/petstore-with-oidc-kuadrant-extensions.yaml", "testdata/petstore_oidc_3_1.yaml"),
+	Entry("Swagger 2.0 HTTPRoute", generateGatewayApiHttpRouteCommand,
+		"testdata/petstore_swagger_2_0_as_3_0.yaml", "testdata/petstore_swagger_2_0.yaml"),
+	Entry("Swagger 2.0 AuthPolicy", generateKuadrantAuthPolicyCommand,
+		"testdata/petstore_swagger_2_0_as_3_0.yaml", "testdata/petstore_swagger_2_0.yaml"),
+	Entry("Swagger 2.0 RateLimitPolicy", generateKuadrantRateLimitPolicyCommand,
+		"testdata/petstore_swagger_2_0_as_3_0.yaml", "testdata/petstore_swagger_2_0.yaml"),
+)
+
+var _ = Describe("OpenAPI document loader", func() {
+	It("mutualTLS security schemes are reported as not supported", func() {
+		cmd := generateKuadrantAuthPolicyCommand()
+		cmd.SetOut(bytes.NewBufferString(""))
+		cmdStderrBuffer := bytes.NewBufferString("")
+		cmd.SetErr(cmdStderrBuffer)
+		cmd.SetArgs([]string{"--oas", "testdata/petstore_oidc_3_1.yaml"})
+		Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+		Expect(cmdStderrBuffer.String()).To(ContainSubstring(
+			`Warning: #/components/securitySchemes/mutualTLS/type: security scheme type "mutualTLS" not supported`,
+		))
+	})
+
+	It("unsupported Swagger version", func() {
+		_, _, err := loadOpenAPIData([]byte(`swagger: "1.2"`), nil, utils.NewExternalResourceReader(nil))
+		Expect(err).To(MatchError(ContainSubstring(`unsupported Swagger version "1.2"`)))
+	})
+
+	It("unsupported OpenAPI version", func() {
+		_, _, err := loadOpenAPIData([]byte(`openapi: "4.0.0"`), nil, utils.NewExternalResourceReader(nil))
+		Expect(err).To(MatchError(ContainSubstring(`unsupported OpenAPI version "4.0.0"`)))
+	})
+
+	Context("with remote documents", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			securityFile, err := filepath.Abs("testdata/multi_file/components/security.yaml")
+			Expect(err).ToNot(HaveOccurred())
+
+			mux := http.NewServeMux()
+			mux.Handle("/multi_file/", http.StripPrefix("/multi_file/", http.FileServer(http.Dir("testdata/multi_file"))))
+			mux.HandleFunc("/local_ref.yaml", func(w http.ResponseWriter, _ *http.Request) {
+				fmt.Fprintf(w, `
+openapi: "3.0.3"
+info:
+  title: "Pet Store API"
+  version: "1.0.0"
+paths: {}
+components:
+  securitySchemes:
+    apiKeyPets:
+      $ref: "file://%s#/apiKeyPets"
+`, filepath.ToSlash(securityFile))
+			})
+			server = httptest.NewServer(mux)
+			DeferCleanup(server.Close)
+		})
+
+		It("references of the same origin resolved", func() {
+			doc, _, err := loadOpenAPIDocument(server.URL+"/multi_file/petstore.yaml", utils.NewExternalResourceReader(nil))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(doc.Components.SecuritySchemes["apiKeyPets"].Value.Name).To(Equal("api_key"))
+		})
+
+		It("references of local files refused", func() {
+			_, _, err := loadOpenAPIDocument(server.URL+"/local_ref.yaml", utils.NewExternalResourceReader(nil))
+			Expect(err).To(MatchError(ContainSubstring(
+				fmt.Sprintf("the remote document %q only references resources of its origin %s", server.URL+"/local_ref.yaml", server.URL),
+			)))
+		})
+	})
+})
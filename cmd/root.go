@@ -51,6 +51,10 @@ func GetRootCmd(args []string) *cobra.Command {
 	rootCmd.AddCommand(versionCommand())
 	rootCmd.AddCommand(generateCommand())
 	rootCmd.AddCommand(topologyCommand())
+	rootCmd.AddCommand(lintCommand())
+	rootCmd.AddCommand(schemaCommand())
+	rootCmd.AddCommand(oasCommand())
+	rootCmd.AddCommand(applyCommand())
 
 	if isBinaryAvailable("kubectl-dns") {
 		rootCmd.AddCommand(dnsCommand())
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+var (
+	schemaExtensionsFormat string
+)
+
+func schemaCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print JSON Schemas related to Kuadrant resource generation",
+		Long:  "Print JSON Schemas related to Kuadrant resource generation",
+	}
+
+	cmd.AddCommand(schemaExtensionsCommand())
+
+	return cmd
+}
+
+//kuadrantctl schema extensions [-o json|yaml]
+
+func schemaExtensionsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "extensions",
+		Short: "Print the JSON Schema of the Kuadrant OpenAPI extensions",
+		Long: `Print the JSON Schema of the Kuadrant OpenAPI extensions.
+The schema is generated from the types the x-kuadrant extensions are parsed to.
+It describes an OpenAPI document, validating the root, path and operation extensions only.`,
+		RunE: runSchemaExtensions,
+	}
+
+	cmd.Flags().StringVarP(&schemaExtensionsFormat, "output-format", "o", "json", "Output format: 'json' or 'yaml'.")
+
+	return cmd
+}
+
+func runSchemaExtensions(cmd *cobra.Command, args []string) error {
+	outputBytes, err := utils.KuadrantOASExtensionsJSONSchema()
+	if err != nil {
+		return err
+	}
+
+	if schemaExtensionsFormat == "yaml" {
+		outputBytes, err = yaml.JSONToYAML(outputBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprint(cmd.OutOrStdout(), string(outputBytes))
+	return nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+var _ = Describe("Schema extensions", func() {
+	var (
+		cmd             *cobra.Command
+		cmdStdoutBuffer *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		cmd = schemaExtensionsCommand()
+		cmdStdoutBuffer = bytes.NewBufferString("")
+		cmd.SetOut(cmdStdoutBuffer)
+		// package level flag vars keep the value of previous runs
+		schemaExtensionsFormat = "json"
+	})
+
+	It("json output", func() {
+		cmd.SetArgs([]string{})
+		Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+		Expect(cmdStdoutBuffer.String()).To(Equal(string(utils.KuadrantOASExtensionsSchema)))
+	})
+
+	It("yaml output", func() {
+		cmd.SetArgs([]string{"-o", "yaml"})
+		Expect(cmd.Execute()).ShouldNot(HaveOccurred())
+
+		var schema map[string]any
+		Expect(yaml.Unmarshal(cmdStdoutBuffer.Bytes(), &schema)).To(Succeed())
+		Expect(schema).To(HaveKeyWithValue("$schema", "http://json-schema.org/draft-07/schema#"))
+	})
+})
@@ -3,133 +3,161 @@ package gatewayapi
 import (
 	"github.com/getkin/kin-openapi/openapi3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/utils/ptr"
 	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	"github.com/kuadrant/kuadrantctl/pkg/utils"
 )
 
-func HTTPRouteObjectMetaFromOAS(doc *openapi3.T) metav1.ObjectMeta {
-	kuadrantRootExtension, err := utils.NewKuadrantOASRootExtension(doc)
+func HTTPRouteObjectMetaFromOAS(doc *openapi3.T) (metav1.ObjectMeta, error) {
+	route, err := routeObjectFromOAS(doc)
 	if err != nil {
-		panic(err)
+		return metav1.ObjectMeta{}, err
 	}
 
-	if kuadrantRootExtension == nil {
-		return metav1.ObjectMeta{}
+	if route == nil {
+		return metav1.ObjectMeta{}, nil
 	}
 
-	if kuadrantRootExtension.Route == nil {
-		panic("openapi root kuadrant extension route not found")
+	if route.Name == nil {
+		return metav1.ObjectMeta{}, utils.NewError(
+			utils.JSONPointer(utils.KuadrantExtensionKey, "route", "name"),
+			"openapi root kuadrant extension route name not found",
+		)
 	}
 
-	if kuadrantRootExtension.Route.Name == nil {
-		panic("openapi root kuadrant extension route name not found")
+	om := metav1.ObjectMeta{
+		Name:   *route.Name,
+		Labels: route.Labels,
 	}
 
-	om := metav1.ObjectMeta{
-		Name:   *kuadrantRootExtension.Route.Name,
-		Labels: kuadrantRootExtension.Route.Labels,
+	if route.Namespace != nil {
+		om.Namespace = *route.Namespace
 	}
 
-	if kuadrantRootExtension.Route.Namespace != nil {
-		om.Namespace = *kuadrantRootExtension.Route.Namespace
+	return om, nil
+}
+
+func HTTPRouteGatewayParentRefsFromOAS(doc *openapi3.T) ([]gatewayapiv1.ParentReference, error) {
+	route, err := routeObjectFromOAS(doc)
+	if err != nil || route == nil {
+		return nil, err
 	}
 
-	return om
+	return route.ParentRefs, nil
 }
 
-func HTTPRouteGatewayParentRefsFromOAS(doc *openapi3.T) []gatewayapiv1.ParentReference {
-	kuadrantRootExtension, err := utils.NewKuadrantOASRootExtension(doc)
+// HTTPRouteHostnamesFromOAS returns the hostnames of the root kuadrant extension route.
+// When not defined, the hostnames are read from the server URLs if enabled by the options.
+func HTTPRouteHostnamesFromOAS(doc *openapi3.T, opts *utils.GenerateOptions) ([]gatewayapiv1.Hostname, error) {
+	route, err := routeObjectFromOAS(doc)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	if kuadrantRootExtension == nil {
-		return nil
+	if route != nil && len(route.Hostnames) > 0 {
+		return route.Hostnames, nil
 	}
 
-	if kuadrantRootExtension.Route == nil {
-		panic("openapi root kuadrant extension route not found")
+	if opts.GetHostnamesFromServers() {
+		return utils.HostnamesFromOASServers(doc, opts)
 	}
 
-	return kuadrantRootExtension.Route.ParentRefs
+	return nil, nil
 }
 
-func HTTPRouteHostnamesFromOAS(doc *openapi3.T) []gatewayapiv1.Hostname {
-	kuadrantRootExtension, err := utils.NewKuadrantOASRootExtension(doc)
+// routeObjectFromOAS returns the route object of the root kuadrant extension.
+// Nil when the root kuadrant extension is not present.
+func routeObjectFromOAS(doc *openapi3.T) (*utils.RouteObject, error) {
+	kuadrantRootExtension, err := utils.KuadrantOASRootExtensionFromOAS(doc)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	if kuadrantRootExtension == nil {
-		return nil
+		return nil, nil
 	}
 
 	if kuadrantRootExtension.Route == nil {
-		panic("openapi root kuadrant extension route not found")
+		return nil, utils.NewError(
+			utils.JSONPointer(utils.KuadrantExtensionKey, "route"),
+			"openapi root kuadrant extension route not found",
+		)
 	}
 
-	return kuadrantRootExtension.Route.Hostnames
+	return kuadrantRootExtension.Route, nil
 }
 
-func HTTPRouteRulesFromOAS(doc *openapi3.T) []gatewayapiv1.HTTPRouteRule {
-	// Current implementation, one rule per operation
-	// TODO(eguzki): consider about grouping operations as HTTPRouteMatch objects in fewer HTTPRouteRule objects
+// HTTPRouteRulesFromOAS returns one rule per operation or, when rule compaction is enabled,
+// the operations grouped in fewer rules with several matches
+func HTTPRouteRulesFromOAS(doc *openapi3.T, opts *utils.GenerateOptions) ([]gatewayapiv1.HTTPRouteRule, error) {
 	rules := make([]gatewayapiv1.HTTPRouteRule, 0)
 
-	basePath, err := utils.BasePathFromOpenAPI(doc)
+	var problems utils.Problems
+
+	rootExtension, err := utils.KuadrantOASRootExtensionFromOAS(doc)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	if err := utils.ValidateServerVariables(doc, opts); err != nil {
+		return nil, err
 	}
 
-	// Paths
-	for path, pathItem := range doc.Paths {
-		kuadrantPathExtension, err := utils.NewKuadrantOASPathExtension(pathItem)
+	// Operations, sorted by path and method
+	for _, oasOperation := range utils.OperationsFromOAS(doc, opts) {
+		path, pathItem, verb, operation := oasOperation.Path, oasOperation.PathItem, oasOperation.Verb, oasOperation.Operation
+
+		// root, path and operation level kuadrant extensions
+		kuadrantExtension, err := utils.NewKuadrantOASEffectiveExtension(rootExtension, oasOperation)
+		if err != nil {
+			problems.Append(err)
+			continue
+		}
+
+		if kuadrantExtension.IsDisabled() {
+			// not enabled for the operation
+			continue
+		}
+
+		// servers may be overridden at the path and operation levels
+		basePath, err := utils.OperationBasePathFromOAS(doc, oasOperation, opts)
 		if err != nil {
-			panic(err)
+			problems.Append(err)
+			continue
 		}
 
-		// Operations
-		for verb, operation := range pathItem.Operations() {
-			kuadrantOperationExtension, err := utils.NewKuadrantOASOperationExtension(operation)
-			if err != nil {
-				panic(err)
-			}
-
-			if ptr.Deref(kuadrantOperationExtension.Disable, kuadrantPathExtension.IsDisabled()) {
-				// not enabled for the operation
-				continue
-			}
-
-			// default backendrefs at the path level
-			backendRefs := kuadrantPathExtension.BackendRefs
-			if len(kuadrantOperationExtension.BackendRefs) > 0 {
-				backendRefs = kuadrantOperationExtension.BackendRefs
-			}
-
-			// default pathMatchType at the path level
-			pathMatchType := ptr.Deref(
-				kuadrantOperationExtension.PathMatchType,
-				kuadrantPathExtension.GetPathMatchType(),
-			)
-
-			rules = append(rules, buildHTTPRouteRule(basePath, path, pathItem, verb, operation, backendRefs, pathMatchType))
+		rule, err := buildHTTPRouteRule(basePath, path, pathItem, verb, operation, kuadrantExtension.BackendRefs, kuadrantExtension.GetPathMatchType(), opts)
+		if err != nil {
+			problems.Append(err)
+			continue
 		}
+
+		rules = append(rules, rule)
+	}
+
+	if err := problems.ErrorOrNil(); err != nil {
+		return nil, err
 	}
 
 	if len(rules) == 0 {
-		return nil
+		return nil, nil
+	}
+
+	if opts.GetCompactRules() {
+		return CompactHTTPRouteRules(rules), nil
 	}
 
-	return rules
+	return rules, nil
 }
 
-func buildHTTPRouteRule(basePath, path string, pathItem *openapi3.PathItem, verb string, op *openapi3.Operation, backendRefs []gatewayapiv1.HTTPBackendRef, pathMatchType gatewayapiv1.PathMatchType) gatewayapiv1.HTTPRouteRule {
-	match := utils.OpenAPIMatcherFromOASOperations(basePath, path, pathItem, verb, op, pathMatchType)
+func buildHTTPRouteRule(basePath, path string, pathItem *openapi3.PathItem, verb string, op *openapi3.Operation, backendRefs []gatewayapiv1.HTTPBackendRef, pathMatchType gatewayapiv1.PathMatchType, opts *utils.GenerateOptions) (gatewayapiv1.HTTPRouteRule, error) {
+	match, err := utils.OpenAPIMatcherFromOASOperations(basePath, path, pathItem, verb, op, pathMatchType, opts)
+	if err != nil {
+		return gatewayapiv1.HTTPRouteRule{}, err
+	}
 
 	return gatewayapiv1.HTTPRouteRule{
 		BackendRefs: backendRefs,
 		Matches:     []gatewayapiv1.HTTPRouteMatch{match},
-	}
+	}, nil
 }
@@ -0,0 +1,95 @@
+package gatewayapi
+
+import (
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"k8s.io/apimachinery/pkg/api/equality"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+// Gateway API limits, enforced by the HTTPRoute CRD validation
+const (
+	MaxHTTPRouteRules       = 16
+	MaxHTTPRouteRuleMatches = 8
+)
+
+// CompactHTTPRouteRules merges the rules with the same backendRefs and filters in one rule with several matches,
+// up to MaxHTTPRouteRuleMatches matches per rule.
+// Rules keep the order of their first match, and matches keep their order within a rule.
+func CompactHTTPRouteRules(rules []gatewayapiv1.HTTPRouteRule) []gatewayapiv1.HTTPRouteRule {
+	compacted := make([]gatewayapiv1.HTTPRouteRule, 0, len(rules))
+
+	for _, rule := range rules {
+		idx := -1
+		for compactedIdx := range compacted {
+			if canMergeHTTPRouteRules(compacted[compactedIdx], rule) {
+				idx = compactedIdx
+				break
+			}
+		}
+
+		if idx == -1 {
+			compacted = append(compacted, *rule.DeepCopy())
+			continue
+		}
+
+		compacted[idx].Matches = append(compacted[idx].Matches, rule.Matches...)
+	}
+
+	return compacted
+}
+
+func canMergeHTTPRouteRules(a, b gatewayapiv1.HTTPRouteRule) bool {
+	return len(a.Matches)+len(b.Matches) <= MaxHTTPRouteRuleMatches &&
+		equality.Semantic.DeepEqual(a.BackendRefs, b.BackendRefs) &&
+		equality.Semantic.DeepEqual(a.Filters, b.Filters)
+}
+
+// SplitHTTPRouteRules splits the rules in groups of up to MaxHTTPRouteRules rules, one group per HTTPRoute.
+// There is always at least one group, even without rules.
+func SplitHTTPRouteRules(rules []gatewayapiv1.HTTPRouteRule) [][]gatewayapiv1.HTTPRouteRule {
+	groups := [][]gatewayapiv1.HTTPRouteRule{}
+
+	for len(rules) > MaxHTTPRouteRules {
+		groups = append(groups, rules[:MaxHTTPRouteRules])
+		rules = rules[MaxHTTPRouteRules:]
+	}
+
+	return append(groups, rules)
+}
+
+// HTTPRouteRulesByRouteFromOAS returns the HTTPRoute rules grouped by HTTPRoute.
+// Unless rule compaction is enabled, one HTTPRoute holds every rule.
+func HTTPRouteRulesByRouteFromOAS(doc *openapi3.T, opts *utils.GenerateOptions) ([][]gatewayapiv1.HTTPRouteRule, error) {
+	rules, err := HTTPRouteRulesFromOAS(doc, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.GetCompactRules() {
+		return [][]gatewayapiv1.HTTPRouteRule{rules}, nil
+	}
+
+	return SplitHTTPRouteRules(rules), nil
+}
+
+// SplitHTTPRouteName returns the name of the HTTPRoute holding the idx-th group of rules.
+// The first HTTPRoute keeps the name defined in the kuadrant extension, the next ones are suffixed with their position.
+// Split HTTPRoutes require the name, otherwise they would share the same empty name.
+func SplitHTTPRouteName(name string, idx int) (string, error) {
+	if idx == 0 {
+		return name, nil
+	}
+
+	if name == "" {
+		return "", utils.NewError(
+			utils.JSONPointer(utils.KuadrantExtensionKey, "route", "name"),
+			"openapi root kuadrant extension route name required to split the rules in several HTTPRoutes",
+		)
+	}
+
+	return fmt.Sprintf("%s-%d", name, idx+1), nil
+}
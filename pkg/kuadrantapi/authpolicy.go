@@ -1,8 +1,12 @@
 package kuadrantapi
 
 import (
-	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	authorinoapi "github.com/kuadrant/authorino/api/v1beta2"
@@ -16,192 +20,678 @@ import (
 )
 
 const (
-	APIKeySecretLabel = "kuadrant.io/apikeys-by"
+	APIKeySecretLabel    = "kuadrant.io/apikeys-by"
+	BasicAuthSecretLabel = "kuadrant.io/basic-auth-by"
 )
 
-func AuthPolicyObjectMetaFromOAS(doc *openapi3.T) metav1.ObjectMeta {
+func AuthPolicyObjectMetaFromOAS(doc *openapi3.T) (metav1.ObjectMeta, error) {
 	return gatewayapi.HTTPRouteObjectMetaFromOAS(doc)
 }
 
-func buildAuthPolicyRouteSelectors(basePath, path string, pathItem *openapi3.PathItem, verb string, op *openapi3.Operation, pathMatchType gatewayapiv1.PathMatchType) []kuadrantapiv1beta2.RouteSelector {
-	match := utils.OpenAPIMatcherFromOASOperations(basePath, path, pathItem, verb, op, pathMatchType)
+// AuthPolicyHasRules tells whether the AuthPolicy holds any authentication, metadata, authorization, response or callbacks rule
+func AuthPolicyHasRules(ap *kuadrantapiv1beta2.AuthPolicy) bool {
+	authScheme := ap.Spec.AuthScheme
+	if authScheme == nil {
+		return false
+	}
+
+	if len(authScheme.Authentication) > 0 || len(authScheme.Metadata) > 0 || len(authScheme.Authorization) > 0 || len(authScheme.Callbacks) > 0 {
+		return true
+	}
+
+	response := authScheme.Response
+	return response != nil && (response.Unauthenticated != nil || response.Unauthorized != nil ||
+		len(response.Success.Headers) > 0 || len(response.Success.DynamicMetadata) > 0)
+}
+
+func buildAuthPolicyRouteSelectors(basePath, path string, pathItem *openapi3.PathItem, verb string, op *openapi3.Operation, pathMatchType gatewayapiv1.PathMatchType, opts *utils.GenerateOptions) ([]kuadrantapiv1beta2.RouteSelector, error) {
+	match, err := utils.OpenAPIMatcherFromOASOperations(basePath, path, pathItem, verb, op, pathMatchType, opts)
+	if err != nil {
+		return nil, err
+	}
 
 	return []kuadrantapiv1beta2.RouteSelector{
 		{
 			Matches: []gatewayapiv1.HTTPRouteMatch{match},
 		},
-	}
+	}, nil
 }
 
-func AuthPolicyTopRouteSelectorsFromOAS(doc *openapi3.T) []kuadrantapiv1beta2.RouteSelector {
+func AuthPolicyTopRouteSelectorsFromOAS(doc *openapi3.T, opts *utils.GenerateOptions) ([]kuadrantapiv1beta2.RouteSelector, error) {
 	routeSelectors := make([]kuadrantapiv1beta2.RouteSelector, 0)
 
-	basePath, err := utils.BasePathFromOpenAPI(doc)
+	var problems utils.Problems
+
+	rootExtension, err := utils.KuadrantOASRootExtensionFromOAS(doc)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	for path, pathItem := range doc.Paths {
-		kuadrantPathExtension, err := utils.NewKuadrantOASPathExtension(pathItem)
+	if err := utils.ValidateServerVariables(doc, opts); err != nil {
+		return nil, err
+	}
+
+	// Operations, sorted by path and method
+	for _, oasOperation := range utils.OperationsFromOAS(doc, opts) {
+		path, pathItem, verb, operation := oasOperation.Path, oasOperation.PathItem, oasOperation.Verb, oasOperation.Operation
+
+		// root, path and operation level kuadrant extensions
+		kuadrantExtension, err := utils.NewKuadrantOASEffectiveExtension(rootExtension, oasOperation)
 		if err != nil {
-			panic(err)
+			problems.Append(err)
+			continue
 		}
 
-		// Operations
-		for verb, operation := range pathItem.Operations() {
-			kuadrantOperationExtension, err := utils.NewKuadrantOASOperationExtension(operation)
-			if err != nil {
-				panic(err)
-			}
+		if kuadrantExtension.IsDisabled() {
+			// not enabled for the operation
+			continue
+		}
 
-			if ptr.Deref(kuadrantOperationExtension.Disable, kuadrantPathExtension.IsDisabled()) {
-				// not enabled for the operation
-				//fmt.Printf("OUT not enabled: path: %s, method: %s\n", path, verb)
-				continue
-			}
+		// servers may be overridden at the path and operation levels
+		basePath, err := utils.OperationBasePathFromOAS(doc, oasOperation, opts)
+		if err != nil {
+			problems.Append(err)
+			continue
+		}
 
-			// Get operation level security requirements or fallback to global security requirements
-			secRequirements := ptr.Deref(operation.Security, doc.Security)
+		// Get operation level security requirements or fallback to global security requirements
+		secRequirements := ptr.Deref(operation.Security, doc.Security)
 
-			// Top RouteSelectors define the matching rules to call external auth service
-			// group together any routes that has at least one security requirement
-			if len(secRequirements) == 0 {
-				// no security
-				continue
-			}
+		// Top RouteSelectors define the matching rules to call external auth service
+		// group together any routes that has at least one security requirement or auth rules
+		if len(secRequirements) == 0 && kuadrantExtension.Auth == nil {
+			// no security
+			continue
+		}
 
-			// default pathMatchType at the path level
-			pathMatchType := ptr.Deref(
-				kuadrantOperationExtension.PathMatchType,
-				kuadrantPathExtension.GetPathMatchType(),
-			)
+		pathMatchType := kuadrantExtension.GetPathMatchType()
 
-			routeSelectors = append(routeSelectors, buildAuthPolicyRouteSelectors(basePath, path, pathItem, verb, operation, pathMatchType)...)
+		operationRouteSelectors, err := buildAuthPolicyRouteSelectors(basePath, path, pathItem, verb, operation, pathMatchType, opts)
+		if err != nil {
+			problems.Append(err)
+			continue
 		}
+
+		routeSelectors = append(routeSelectors, operationRouteSelectors...)
+	}
+
+	if err := problems.ErrorOrNil(); err != nil {
+		return nil, err
 	}
 
 	if len(routeSelectors) == 0 {
-		return nil
+		return nil, nil
 	}
 
-	return routeSelectors
+	if opts.GetCompactRules() {
+		routeSelectors = compactRouteSelectors(routeSelectors)
+	}
+
+	return routeSelectors, nil
 }
 
-func AuthPolicyAuthenticationSchemeFromOAS(doc *openapi3.T) map[string]kuadrantapiv1beta2.AuthenticationSpec {
+func AuthPolicyAuthenticationSchemeFromOAS(doc *openapi3.T, opts *utils.GenerateOptions) (map[string]kuadrantapiv1beta2.AuthenticationSpec, error) {
 	authentication := make(map[string]kuadrantapiv1beta2.AuthenticationSpec)
 
-	basePath, err := utils.BasePathFromOpenAPI(doc)
+	var problems utils.Problems
+
+	rootExtension, err := utils.KuadrantOASRootExtensionFromOAS(doc)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	// Paths
-	for path, pathItem := range doc.Paths {
-		kuadrantPathExtension, err := utils.NewKuadrantOASPathExtension(pathItem)
+	if err := utils.ValidateServerVariables(doc, opts); err != nil {
+		return nil, err
+	}
+
+	// Operations, sorted by path and method
+	for _, oasOperation := range utils.OperationsFromOAS(doc, opts) {
+		path, pathItem, verb, operation := oasOperation.Path, oasOperation.PathItem, oasOperation.Verb, oasOperation.Operation
+
+		// root, path and operation level kuadrant extensions
+		kuadrantExtension, err := utils.NewKuadrantOASEffectiveExtension(rootExtension, oasOperation)
 		if err != nil {
-			panic(err)
+			problems.Append(err)
+			continue
 		}
 
-		// Operations
-		for verb, operation := range pathItem.Operations() {
-			kuadrantOperationExtension, err := utils.NewKuadrantOASOperationExtension(operation)
-			if err != nil {
-				panic(err)
+		if kuadrantExtension.IsDisabled() {
+			// not enabled for the operation
+			continue
+		}
+
+		// servers may be overridden at the path and operation levels
+		basePath, err := utils.OperationBasePathFromOAS(doc, oasOperation, opts)
+		if err != nil {
+			problems.Append(err)
+			continue
+		}
+
+		// Get operation level security requirements or fallback to global security requirements
+		secRequirements, err := operationSecurityRequirements(doc, oasOperation)
+		if err != nil {
+			problems.Append(err)
+			continue
+		}
+
+		if len(secRequirements) == 0 {
+			// no security
+			continue
+		}
+
+		pathMatchType := kuadrantExtension.GetPathMatchType()
+
+		operationAuthentication, err := buildOperationAuthentication(doc, opts, basePath, path, pathItem, verb, operation, pathMatchType, secRequirements)
+		if err != nil {
+			problems.Append(err)
+			continue
+		}
+
+		if opts.GetAuthenticationPerOperation() {
+			// Aggregate auth methods per operation
+			for _, secSchemeName := range utils.SortedKeys(operationAuthentication) {
+				authName := fmt.Sprintf("%s_%s", utils.OpenAPIOperationName(path, verb, operation), secSchemeName)
+				authentication[authName] = operationAuthentication[secSchemeName]
 			}
+			continue
+		}
 
-			if ptr.Deref(kuadrantOperationExtension.Disable, kuadrantPathExtension.IsDisabled()) {
-				// not enabled for the operation
-				//fmt.Printf("OUT not enabled: path: %s, method: %s\n", path, verb)
+		// Aggregate the route selectors of the operations per auth method
+		for _, secSchemeName := range utils.SortedKeys(operationAuthentication) {
+			schemeAuthentication, ok := authentication[secSchemeName]
+			if !ok {
+				authentication[secSchemeName] = operationAuthentication[secSchemeName]
 				continue
 			}
+			schemeAuthentication.RouteSelectors = append(schemeAuthentication.RouteSelectors, operationAuthentication[secSchemeName].RouteSelectors...)
+			schemeAuthentication.Priority = max(schemeAuthentication.Priority, operationAuthentication[secSchemeName].Priority)
+			authentication[secSchemeName] = schemeAuthentication
+		}
+	}
 
-			// Get operation level security requirements or fallback to global security requirements
-			secRequirements := ptr.Deref(operation.Security, doc.Security)
+	if err := problems.ErrorOrNil(); err != nil {
+		return nil, err
+	}
 
-			if len(secRequirements) == 0 {
-				// no security
-				continue
-			}
+	if !opts.GetAuthenticationPerOperation() {
+		authentication = splitAuthenticationRouteSelectors(authentication)
+	}
 
-			// default pathMatchType at the path level
-			pathMatchType := ptr.Deref(
-				kuadrantOperationExtension.PathMatchType,
-				kuadrantPathExtension.GetPathMatchType(),
-			)
+	if len(authentication) == 0 {
+		return nil, nil
+	}
 
-			operationAuthentication := buildOperationAuthentication(doc, basePath, path, pathItem, verb, operation, pathMatchType, secRequirements)
+	return authentication, nil
+}
 
-			// Aggregate auth methods per operation
-			authentication = utils.MergeMaps(authentication, operationAuthentication)
+// splitAuthenticationRouteSelectors groups the matches of the route selectors of every authentication rule
+// in route selectors of up to MaxRouteSelectorMatches matches, and splits the rules exceeding MaxRouteSelectors
+// route selectors in several rules. The first rule keeps its name, the next ones are suffixed with their position.
+func splitAuthenticationRouteSelectors(authentication map[string]kuadrantapiv1beta2.AuthenticationSpec) map[string]kuadrantapiv1beta2.AuthenticationSpec {
+	split := make(map[string]kuadrantapiv1beta2.AuthenticationSpec, len(authentication))
+
+	for name, authenticationSpec := range authentication {
+		for idx, routeSelectors := range splitRouteSelectors(compactRouteSelectors(authenticationSpec.RouteSelectors)) {
+			ruleSpec := *authenticationSpec.DeepCopy()
+			ruleSpec.RouteSelectors = routeSelectors
+			split[splitAuthRuleName(name, idx)] = ruleSpec
 		}
 	}
 
-	if len(authentication) == 0 {
-		return nil
+	return split
+}
+
+// AuthPolicyAuthorizationFromOAS returns the authorization rules enforcing the scopes required by the security requirements.
+func AuthPolicyAuthorizationFromOAS(doc *openapi3.T, opts *utils.GenerateOptions) (map[string]kuadrantapiv1beta2.AuthorizationSpec, error) {
+	authorization := make(map[string]kuadrantapiv1beta2.AuthorizationSpec)
+
+	var problems utils.Problems
+
+	rootExtension, err := utils.KuadrantOASRootExtensionFromOAS(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.ValidateServerVariables(doc, opts); err != nil {
+		return nil, err
+	}
+
+	// Operations, sorted by path and method
+	for _, oasOperation := range utils.OperationsFromOAS(doc, opts) {
+		path, pathItem, verb, operation := oasOperation.Path, oasOperation.PathItem, oasOperation.Verb, oasOperation.Operation
+
+		// root, path and operation level kuadrant extensions
+		kuadrantExtension, err := utils.NewKuadrantOASEffectiveExtension(rootExtension, oasOperation)
+		if err != nil {
+			problems.Append(err)
+			continue
+		}
+
+		if kuadrantExtension.IsDisabled() {
+			// not enabled for the operation
+			continue
+		}
+
+		// servers may be overridden at the path and operation levels
+		basePath, err := utils.OperationBasePathFromOAS(doc, oasOperation, opts)
+		if err != nil {
+			problems.Append(err)
+			continue
+		}
+
+		// Get operation level security requirements or fallback to global security requirements
+		secRequirements, err := operationSecurityRequirements(doc, oasOperation)
+		if err != nil {
+			problems.Append(err)
+			continue
+		}
+
+		if len(secRequirements) == 0 {
+			// no security
+			continue
+		}
+
+		pathMatchType := kuadrantExtension.GetPathMatchType()
+
+		operationAuthorization, err := buildOperationAuthorization(doc, opts, basePath, path, pathItem, verb, operation, pathMatchType, secRequirements)
+		if err != nil {
+			problems.Append(err)
+			continue
+		}
+
+		authorization = utils.MergeMaps(authorization, operationAuthorization)
+	}
+
+	if err := problems.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
+	if len(authorization) == 0 {
+		return nil, nil
 	}
 
-	return authentication
+	return authorization, nil
 }
 
-func buildOperationAuthentication(doc *openapi3.T, basePath, path string, pathItem *openapi3.PathItem, verb string, op *openapi3.Operation, pathMatchType gatewayapiv1.PathMatchType, secRequirements openapi3.SecurityRequirements) map[string]kuadrantapiv1beta2.AuthenticationSpec {
+// operationSecurityRequirements returns the operation level security requirements or, when not defined, the global ones.
+// The security requirements of operations with several security schemes required (AND'ed) are reported as errors
+// when the credentials of their security schemes cannot be verified together, see validateANDedSecurityRequirements.
+func operationSecurityRequirements(doc *openapi3.T, oasOperation utils.OASOperation) (openapi3.SecurityRequirements, error) {
+	secRequirements := doc.Security
+	pointer := func(idx int) string { return utils.JSONPointer("security", strconv.Itoa(idx)) }
+	if oasOperation.Operation.Security != nil {
+		secRequirements = *oasOperation.Operation.Security
+		pointer = func(idx int) string {
+			return utils.OperationJSONPointer(oasOperation.Path, oasOperation.Verb, "security", strconv.Itoa(idx))
+		}
+	}
+
+	if andedSecurityRequirements(secRequirements) {
+		if err := validateANDedSecurityRequirements(doc, secRequirements, pointer); err != nil {
+			return nil, err
+		}
+	}
+
+	return secRequirements, nil
+}
+
+// buildOperationAuthentication returns the authentication rules of the operation, keyed by security scheme name
+func buildOperationAuthentication(doc *openapi3.T, opts *utils.GenerateOptions, basePath, path string, pathItem *openapi3.PathItem, verb string, op *openapi3.Operation, pathMatchType gatewayapiv1.PathMatchType, secRequirements openapi3.SecurityRequirements) (map[string]kuadrantapiv1beta2.AuthenticationSpec, error) {
 	// OpenAPI supports as security requirement to have multiple security schemes and ALL
 	// of the must be satisfied.
 	// From https://github.com/OAI/OpenAPI-Specification/blob/main/versions/3.0.3.md#security-requirement-object
-	// Kuadrant does not support it yet: https://github.com/Kuadrant/authorino/issues/112
-	// not supported (AND'ed)
+	// Every security scheme becomes an authentication rule, whether OR'ed or AND'ed.
+	// AND'ed security schemes are additionally enforced by an authorization rule,
+	// see buildOperationSecurityAuthorization
+	// AND'ed
 	// security:
 	//   - petstore_api_key: []
 	//     petstore_oidc: []
-	// supported (OR'ed)
+	// OR'ed
 	// security:
 	//   - petstore_api_key: []
 	//   - petstore_oidc: []
 
+	routeSelectors, err := buildAuthPolicyRouteSelectors(basePath, path, pathItem, verb, op, pathMatchType, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems utils.Problems
+
 	opAuth := make(map[string]kuadrantapiv1beta2.AuthenticationSpec, 0)
 	for _, secReq := range secRequirements {
-		if len(secReq) > 1 {
-			panic(errors.New("multiple schemes that require ALL must be satisfied, currently not supported"))
+		for _, secReqItemName := range securitySchemeNames(secReq) {
+			secScheme, ok := doc.Components.SecuritySchemes[secReqItemName]
+			if !ok {
+				// should never happen. OpenAPI validation should detect this issue
+				continue
+			}
+
+			if secScheme == nil || secScheme.Value == nil {
+				continue
+			}
+
+			authName := secReqItemName
+
+			// Ref https://github.com/OAI/OpenAPI-Specification/blob/main/versions/3.0.3.md#fixed-fields-23
+			switch secScheme.Value.Type {
+			case "openIdConnect":
+				opAuth[authName] = openIDAuthenticationSpec(routeSelectors, *secScheme.Value)
+			case "apiKey":
+				opAuth[authName] = apiKeyAuthenticationSpec(routeSelectors, secReqItemName, *secScheme.Value)
+			case "oauth2":
+				authenticationSpec, err := tokenAuthenticationSpec(routeSelectors, secReqItemName, *secScheme.Value)
+				if err != nil {
+					problems.Append(err)
+					continue
+				}
+				opAuth[authName] = authenticationSpec
+			case "http":
+				switch strings.ToLower(secScheme.Value.Scheme) {
+				case "bearer":
+					authenticationSpec, err := tokenAuthenticationSpec(routeSelectors, secReqItemName, *secScheme.Value)
+					if err != nil {
+						problems.Append(err)
+						continue
+					}
+					opAuth[authName] = authenticationSpec
+				case "basic":
+					opAuth[authName] = basicAuthenticationSpec(routeSelectors, secReqItemName)
+				default:
+					opts.Warn(utils.NewWarning(
+						utils.JSONPointer("components", "securitySchemes", secReqItemName, "scheme"),
+						"http security scheme %q not supported, ignored by the AuthPolicy generator", secScheme.Value.Scheme,
+					))
+				}
+			default:
+				opts.Warn(utils.NewWarning(
+					utils.JSONPointer("components", "securitySchemes", secReqItemName, "type"),
+					"security scheme type %q not supported, ignored by the AuthPolicy generator", secScheme.Value.Type,
+				))
+			}
 		}
+	}
+
+	if err := problems.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
+	if len(opAuth) == 0 {
+		return nil, nil
+	}
 
-		extractSecReqItemName := func(sr openapi3.SecurityRequirement) string {
-			for secReqItemName := range sr {
-				return secReqItemName
+	if andedSecurityRequirements(secRequirements) {
+		// the identity must be resolved from the credentials verified against Secrets, when presented
+		for authName, authenticationSpec := range opAuth {
+			if authenticationSpec.Jwt != nil {
+				authenticationSpec.Priority = jwtAuthenticationPriority
+				opAuth[authName] = authenticationSpec
 			}
+		}
+	}
 
-			return ""
+	return opAuth, nil
+}
+
+// buildOperationAuthorization returns the authorization rules of the operation: one rule requiring the scopes of any of the
+// security requirements of the token security schemes, or the rule enforcing the security requirements of the operations with AND'ed security schemes.
+func buildOperationAuthorization(doc *openapi3.T, opts *utils.GenerateOptions, basePath, path string, pathItem *openapi3.PathItem, verb string, op *openapi3.Operation, pathMatchType gatewayapiv1.PathMatchType, secRequirements openapi3.SecurityRequirements) (map[string]kuadrantapiv1beta2.AuthorizationSpec, error) {
+	routeSelectors, err := buildAuthPolicyRouteSelectors(basePath, path, pathItem, verb, op, pathMatchType, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	operationName := utils.OpenAPIOperationName(path, verb, op)
+
+	if andedSecurityRequirements(secRequirements) {
+		// scopes verified with the JWTs
+		return buildOperationSecurityAuthorization(doc, routeSelectors, operationName, secRequirements)
+	}
+
+	var problems utils.Problems
+
+	// One group of patterns per security requirement of the token security schemes, any of them matched.
+	// With several token security schemes, the patterns of a security requirement match the tokens of its security scheme only,
+	// told apart by their issuer. Tokens validated by introspection cannot be told apart.
+	tokenSchemeNames := slices.DeleteFunc(securityRequirementsSchemeNames(secRequirements), func(name string) bool {
+		secScheme, ok := doc.Components.SecuritySchemes[name]
+		return !ok || secScheme == nil || secScheme.Value == nil || !tokenSecurityScheme(*secScheme.Value)
+	})
+
+	var groups [][]authorinoapi.PatternExpressionOrRef
+	var credentialsPatterns []authorinoapi.PatternExpression
+	scoped := false
+	for _, secReqItemName := range tokenSchemeNames {
+		secScheme := doc.Components.SecuritySchemes[secReqItemName].Value
+
+		kuadrantSecSchemeExtension, err := utils.NewKuadrantOASSecuritySchemeExtension(secScheme)
+		if err != nil {
+			problems.Add(utils.NewError(
+				utils.JSONPointer("components", "securitySchemes", secReqItemName, utils.KuadrantExtensionKey),
+				"invalid openapi security scheme kuadrant extension: %v", err,
+			))
+			continue
 		}
 
-		secReqItemName := extractSecReqItemName(secReq)
+		var issuerPatterns []authorinoapi.PatternExpressionOrRef
+		if len(tokenSchemeNames) > 1 {
+			issuerURL, err := jwtIssuerURL(secReqItemName, *secScheme)
+			if err != nil {
+				problems.Append(err)
+				continue
+			}
+			if issuerURL != "" {
+				issuerPatterns = append(issuerPatterns, authorinoapi.PatternExpressionOrRef{PatternExpression: authorinoapi.PatternExpression{
+					Selector: "auth.identity.iss",
+					Operator: authorinoapi.PatternExpressionOperator("eq"),
+					Value:    issuerURL,
+				}})
+			}
+		}
 
-		secScheme, ok := doc.Components.SecuritySchemes[secReqItemName]
-		if !ok {
-			// should never happen. OpenAPI validation should detect this issue
+		if credentialsPattern := tokenCredentialsPattern(*secScheme); !slices.Contains(credentialsPatterns, credentialsPattern) {
+			credentialsPatterns = append(credentialsPatterns, credentialsPattern)
+		}
+
+		scopes := securityRequirementsScopes(secRequirements, secReqItemName)
+		if len(scopes) == 0 {
+			// any token of the security scheme is accepted
+			groups = append(groups, issuerPatterns)
+			continue
+		}
+
+		scoped = true
+		for _, requirementScopes := range scopes {
+			groups = append(groups, append(slices.Clone(issuerPatterns), scopesPatterns(kuadrantSecSchemeExtension.GetScopesClaim(), requirementScopes)...))
+		}
+	}
+
+	if err := problems.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
+	if !scoped || slices.ContainsFunc(groups, func(group []authorinoapi.PatternExpressionOrRef) bool { return len(group) == 0 }) {
+		// no scope required, or any token accepted
+		return nil, nil
+	}
+
+	// With alternative security requirements of other security schemes, the scopes are only required from requests presenting a token
+	var conditions []authorinoapi.PatternExpressionOrRef
+	if slices.ContainsFunc(secRequirements, func(secReq openapi3.SecurityRequirement) bool {
+		return !slices.ContainsFunc(tokenSchemeNames, func(name string) bool { _, ok := secReq[name]; return ok })
+	}) {
+		credentialsGroups := make([][]authorinoapi.PatternExpressionOrRef, 0, len(credentialsPatterns))
+		for _, pattern := range credentialsPatterns {
+			credentialsGroups = append(credentialsGroups, []authorinoapi.PatternExpressionOrRef{{PatternExpression: pattern}})
+		}
+		conditions = anyOfPatterns(credentialsGroups)
+	}
+
+	authName := fmt.Sprintf("%s_scopes", operationName)
+
+	return map[string]kuadrantapiv1beta2.AuthorizationSpec{
+		authName: patternMatchingAuthorizationSpec(routeSelectors, conditions, anyOfPatterns(groups)),
+	}, nil
+}
+
+// anyOfPatterns returns the patterns matching any of the groups of patterns, the patterns of a group all matched
+func anyOfPatterns(groups [][]authorinoapi.PatternExpressionOrRef) []authorinoapi.PatternExpressionOrRef {
+	if len(groups) == 1 {
+		return groups[0]
+	}
+
+	anyOf := make([]authorinoapi.UnstructuredPatternExpressionOrRef, 0, len(groups))
+	for _, patterns := range groups {
+		if len(patterns) == 1 {
+			anyOf = append(anyOf, authorinoapi.UnstructuredPatternExpressionOrRef{PatternExpressionOrRef: patterns[0]})
 			continue
 		}
 
-		if secScheme == nil || secScheme.Value == nil {
+		allOf := make([]authorinoapi.UnstructuredPatternExpressionOrRef, 0, len(patterns))
+		for _, pattern := range patterns {
+			allOf = append(allOf, authorinoapi.UnstructuredPatternExpressionOrRef{PatternExpressionOrRef: pattern})
+		}
+		anyOf = append(anyOf, authorinoapi.UnstructuredPatternExpressionOrRef{
+			PatternExpressionOrRef: authorinoapi.PatternExpressionOrRef{All: allOf},
+		})
+	}
+
+	return []authorinoapi.PatternExpressionOrRef{{Any: anyOf}}
+}
+
+// scopesPatterns returns the patterns matching tokens granted all the scopes in the claim.
+// The scope claim is a space-separated list, the roles claim an array and the scp claim either of them.
+func scopesPatterns(claim utils.ScopesClaim, scopes []string) []authorinoapi.PatternExpressionOrRef {
+	selector := fmt.Sprintf("auth.identity.%s", claim)
+
+	patterns := make([]authorinoapi.PatternExpressionOrRef, 0, len(scopes))
+	for _, scope := range scopes {
+		inList := authorinoapi.PatternExpression{
+			Selector: selector,
+			Operator: authorinoapi.PatternExpressionOperator("matches"),
+			Value:    `(?:^|\s)` + regexp.QuoteMeta(scope) + `(?:\s|$)`,
+		}
+		inArray := authorinoapi.PatternExpression{
+			Selector: selector,
+			Operator: authorinoapi.PatternExpressionOperator("incl"),
+			Value:    scope,
+		}
+
+		switch claim {
+		case utils.ScopesClaimRoles:
+			patterns = append(patterns, authorinoapi.PatternExpressionOrRef{PatternExpression: inArray})
+		case utils.ScopesClaimScp:
+			patterns = append(patterns, authorinoapi.PatternExpressionOrRef{Any: []authorinoapi.UnstructuredPatternExpressionOrRef{
+				{PatternExpressionOrRef: authorinoapi.PatternExpressionOrRef{PatternExpression: inArray}},
+				{PatternExpressionOrRef: authorinoapi.PatternExpressionOrRef{PatternExpression: inList}},
+			}})
+		default:
+			patterns = append(patterns, authorinoapi.PatternExpressionOrRef{PatternExpression: inList})
+		}
+	}
+
+	return patterns
+}
+
+// tokenSecurityScheme tells whether the credentials of the security scheme are bearer tokens, granted scopes
+func tokenSecurityScheme(secScheme openapi3.SecurityScheme) bool {
+	switch secScheme.Type {
+	case "openIdConnect", "oauth2":
+		return true
+	case "http":
+		return strings.EqualFold(secScheme.Scheme, "bearer")
+	}
+
+	return false
+}
+
+func patternMatchingAuthorizationSpec(routeSelectors []kuadrantapiv1beta2.RouteSelector, conditions, patterns []authorinoapi.PatternExpressionOrRef) kuadrantapiv1beta2.AuthorizationSpec {
+	return kuadrantapiv1beta2.AuthorizationSpec{
+		CommonAuthRuleSpec: kuadrantapiv1beta2.CommonAuthRuleSpec{
+			RouteSelectors: routeSelectors,
+		},
+		AuthorizationSpec: authorinoapi.AuthorizationSpec{
+			CommonEvaluatorSpec: authorinoapi.CommonEvaluatorSpec{
+				Conditions: conditions,
+			},
+			AuthorizationMethodSpec: authorinoapi.AuthorizationMethodSpec{
+				PatternMatching: &authorinoapi.PatternMatchingAuthorizationSpec{
+					Patterns: patterns,
+				},
+			},
+		},
+	}
+}
+
+// tokenCredentialsPattern returns the pattern matching requests presenting a bearer token,
+// the credentials of the token security schemes
+func tokenCredentialsPattern(secScheme openapi3.SecurityScheme) authorinoapi.PatternExpression {
+	value := `^[Bb]earer\s+\S+`
+	if secScheme.Type == "http" {
+		// HTTP authentication schemes are case-insensitive
+		// Ref https://datatracker.ietf.org/doc/html/rfc7235#section-2.1
+		value = `^(?i:bearer)\s+\S+`
+	}
+
+	return authorinoapi.PatternExpression{
+		Selector: "context.request.http.headers.authorization",
+		Operator: authorinoapi.PatternExpressionOperator("matches"),
+		Value:    value,
+	}
+}
+
+// securityRequirementsSchemeNames returns the sorted names of the security schemes of all the security requirements
+func securityRequirementsSchemeNames(secRequirements openapi3.SecurityRequirements) []string {
+	var names []string
+	for _, secReq := range secRequirements {
+		for _, name := range securitySchemeNames(secReq) {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// securityRequirementsScopes returns the scopes of the security scheme required by the security requirements,
+// one list per security requirement of the security scheme, in order. Duplicated lists are removed.
+// Nil when a security requirement of the security scheme requires no scope.
+func securityRequirementsScopes(secRequirements openapi3.SecurityRequirements, secSchemeName string) [][]string {
+	var scopes [][]string
+	for _, secReq := range secRequirements {
+		requirementScopes, ok := secReq[secSchemeName]
+		if !ok {
 			continue
 		}
 
-		authName := fmt.Sprintf("%s_%s", utils.OpenAPIOperationName(path, verb, op), secReqItemName)
+		if len(requirementScopes) == 0 {
+			// any token of the security scheme is accepted
+			return nil
+		}
 
-		// Ref https://github.com/OAI/OpenAPI-Specification/blob/main/versions/3.0.3.md#fixed-fields-23
-		switch secScheme.Value.Type {
-		case "openIdConnect":
-			opAuth[authName] = openIDAuthenticationSpec(basePath, path, pathItem, verb, op, pathMatchType, *secScheme.Value)
-		case "apiKey":
-			opAuth[authName] = apiKeyAuthenticationSpec(basePath, path, pathItem, verb, op, pathMatchType, secReqItemName, *secScheme.Value)
+		if !slices.ContainsFunc(scopes, func(s []string) bool { return slices.Equal(s, requirementScopes) }) {
+			scopes = append(scopes, requirementScopes)
 		}
 	}
 
-	if len(opAuth) == 0 {
-		return nil
+	return scopes
+}
+
+// securitySchemeNames returns the sorted names of the security schemes of the security requirement
+func securitySchemeNames(secReq openapi3.SecurityRequirement) []string {
+	names := make([]string, 0, len(secReq))
+	for name := range secReq {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	return opAuth
+	return names
 }
 
-func apiKeyAuthenticationSpec(basePath, path string, pathItem *openapi3.PathItem, verb string, op *openapi3.Operation, pathMatchType gatewayapiv1.PathMatchType, secSchemeName string, secScheme openapi3.SecurityScheme) kuadrantapiv1beta2.AuthenticationSpec {
+func apiKeyAuthenticationSpec(routeSelectors []kuadrantapiv1beta2.RouteSelector, secSchemeName string, secScheme openapi3.SecurityScheme) kuadrantapiv1beta2.AuthenticationSpec {
 	// From https://github.com/OAI/OpenAPI-Specification/blob/main/versions/3.0.3.md#fixed-fields-23
 	// secScheme.In is required
 	// secScheme.Name is required
@@ -219,7 +709,7 @@ func apiKeyAuthenticationSpec(basePath, path string, pathItem *openapi3.PathItem
 
 	return kuadrantapiv1beta2.AuthenticationSpec{
 		CommonAuthRuleSpec: kuadrantapiv1beta2.CommonAuthRuleSpec{
-			RouteSelectors: buildAuthPolicyRouteSelectors(basePath, path, pathItem, verb, op, pathMatchType),
+			RouteSelectors: routeSelectors,
 		},
 		AuthenticationSpec: authorinoapi.AuthenticationSpec{
 			Credentials: credentials,
@@ -238,10 +728,10 @@ func apiKeyAuthenticationSpec(basePath, path string, pathItem *openapi3.PathItem
 	}
 }
 
-func openIDAuthenticationSpec(basePath, path string, pathItem *openapi3.PathItem, verb string, op *openapi3.Operation, pathMatchType gatewayapiv1.PathMatchType, secScheme openapi3.SecurityScheme) kuadrantapiv1beta2.AuthenticationSpec {
+func openIDAuthenticationSpec(routeSelectors []kuadrantapiv1beta2.RouteSelector, secScheme openapi3.SecurityScheme) kuadrantapiv1beta2.AuthenticationSpec {
 	return kuadrantapiv1beta2.AuthenticationSpec{
 		CommonAuthRuleSpec: kuadrantapiv1beta2.CommonAuthRuleSpec{
-			RouteSelectors: buildAuthPolicyRouteSelectors(basePath, path, pathItem, verb, op, pathMatchType),
+			RouteSelectors: routeSelectors,
 		},
 		AuthenticationSpec: authorinoapi.AuthenticationSpec{
 			AuthenticationMethodSpec: authorinoapi.AuthenticationMethodSpec{
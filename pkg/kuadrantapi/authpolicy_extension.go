@@ -0,0 +1,317 @@
+package kuadrantapi
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	authorinoapi "github.com/kuadrant/authorino/api/v1beta2"
+	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+// AuthPolicyAuthSchemeExtensionFromOAS returns the metadata, authorization, response and callbacks rules
+// of the auth kuadrant extensions, scoped by the route selectors of the operations, along with the authorization
+// rules generated from the security requirements.
+// The operation auth extension replaces the path auth extension.
+// Rules keep their names, which may be referenced from other rules. A rule defined by several operations
+// must be the same for all of them, it is scoped by the route selectors of all of them.
+// Authorization rules named like the generated authorization rules are reported as conflicts.
+func AuthPolicyAuthSchemeExtensionFromOAS(doc *openapi3.T, opts *utils.GenerateOptions, generatedAuthorization map[string]kuadrantapiv1beta2.AuthorizationSpec) (*kuadrantapiv1beta2.AuthSchemeSpec, error) {
+	authScheme := &kuadrantapiv1beta2.AuthSchemeSpec{}
+
+	var problems utils.Problems
+
+	// location of the rules in the auth extension of the first operation defining them
+	rulePointers := make(map[string]string)
+
+	rootExtension, err := utils.KuadrantOASRootExtensionFromOAS(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.ValidateServerVariables(doc, opts); err != nil {
+		return nil, err
+	}
+
+	// Operations, sorted by path and method
+	for _, oasOperation := range utils.OperationsFromOAS(doc, opts) {
+		path, pathItem, verb, operation := oasOperation.Path, oasOperation.PathItem, oasOperation.Verb, oasOperation.Operation
+
+		// root, path and operation level kuadrant extensions
+		kuadrantExtension, err := utils.NewKuadrantOASEffectiveExtension(rootExtension, oasOperation)
+		if err != nil {
+			problems.Append(err)
+			continue
+		}
+
+		if kuadrantExtension.IsDisabled() {
+			// not enabled for the operation
+			continue
+		}
+
+		auth := kuadrantExtension.Auth
+		if auth == nil {
+			// no auth rules defined for this operation
+			continue
+		}
+
+		// servers may be overridden at the path and operation levels
+		basePath, err := utils.OperationBasePathFromOAS(doc, oasOperation, opts)
+		if err != nil {
+			problems.Append(err)
+			continue
+		}
+
+		pathMatchType := kuadrantExtension.GetPathMatchType()
+
+		routeSelectors, err := buildAuthPolicyRouteSelectors(basePath, path, pathItem, verb, operation, pathMatchType, opts)
+		if err != nil {
+			problems.Append(err)
+			continue
+		}
+
+		for _, name := range utils.SortedKeys(auth.Authorization) {
+			if _, ok := generatedAuthorization[name]; ok {
+				problems.Add(utils.NewError(
+					utils.JSONPointerAppend(kuadrantExtension.AuthPointer, "authorization", name),
+					"auth rule %q conflicts with the authorization rule of the same name generated from the security requirements", name,
+				))
+			}
+		}
+
+		for _, conflict := range addOperationAuthExtension(authScheme, routeSelectors, auth) {
+			problems.Add(utils.NewError(
+				utils.JSONPointerAppend(kuadrantExtension.AuthPointer, conflict...),
+				"auth rule %q differs from the auth rule of the same name of another operation", conflict[len(conflict)-1],
+			))
+		}
+
+		for _, location := range authExtensionRuleLocations(auth) {
+			if _, ok := rulePointers[strings.Join(location, "/")]; !ok {
+				rulePointers[strings.Join(location, "/")] = utils.JSONPointerAppend(kuadrantExtension.AuthPointer, location...)
+			}
+		}
+	}
+
+	// the rules keep their names, referenced from other rules, thus cannot be split like the authentication rules
+	exceeding := slices.Concat(
+		compactAuthRulesRouteSelectors([]string{"metadata"}, authScheme.Metadata, metadataCommonSpec),
+		compactAuthRulesRouteSelectors([]string{"authorization"}, authScheme.Authorization, authorizationCommonSpec),
+		compactAuthRulesRouteSelectors([]string{"callbacks"}, authScheme.Callbacks, callbackCommonSpec),
+	)
+	if authScheme.Response != nil {
+		exceeding = slices.Concat(
+			exceeding,
+			compactAuthRulesRouteSelectors([]string{"response", "success", "headers"}, authScheme.Response.Success.Headers, headerSuccessResponseCommonSpec),
+			compactAuthRulesRouteSelectors([]string{"response", "success", "dynamicMetadata"}, authScheme.Response.Success.DynamicMetadata, successResponseCommonSpec),
+		)
+	}
+	for _, location := range exceeding {
+		problems.Add(utils.NewError(
+			rulePointers[strings.Join(location, "/")],
+			"auth rule %q selects more operations than fit in %d route selectors of %d matches", location[len(location)-1], MaxRouteSelectors, MaxRouteSelectorMatches,
+		))
+	}
+
+	if err := problems.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
+	if len(generatedAuthorization) > 0 {
+		authScheme.Authorization = utils.MergeMaps(authScheme.Authorization, generatedAuthorization)
+	}
+
+	return authScheme, nil
+}
+
+// addOperationAuthExtension adds the rules of the auth extension to the auth scheme.
+// Returns the location in the auth extension of the rules conflicting with the rules already added.
+func addOperationAuthExtension(authScheme *kuadrantapiv1beta2.AuthSchemeSpec, routeSelectors []kuadrantapiv1beta2.RouteSelector, auth *utils.KuadrantAuthExtension) [][]string {
+	var conflicts [][]string
+
+	commonSpec := kuadrantapiv1beta2.CommonAuthRuleSpec{RouteSelectors: routeSelectors}
+
+	for _, name := range utils.SortedKeys(auth.Metadata) {
+		rule := kuadrantapiv1beta2.MetadataSpec{MetadataSpec: auth.Metadata[name], CommonAuthRuleSpec: commonSpec}
+		if !addAuthRule(&authScheme.Metadata, name, rule, metadataCommonSpec) {
+			conflicts = append(conflicts, []string{"metadata", name})
+		}
+	}
+
+	for _, name := range utils.SortedKeys(auth.Authorization) {
+		rule := kuadrantapiv1beta2.AuthorizationSpec{AuthorizationSpec: auth.Authorization[name], CommonAuthRuleSpec: commonSpec}
+		if !addAuthRule(&authScheme.Authorization, name, rule, authorizationCommonSpec) {
+			conflicts = append(conflicts, []string{"authorization", name})
+		}
+	}
+
+	for _, name := range utils.SortedKeys(auth.Callbacks) {
+		rule := kuadrantapiv1beta2.CallbackSpec{CallbackSpec: auth.Callbacks[name], CommonAuthRuleSpec: commonSpec}
+		if !addAuthRule(&authScheme.Callbacks, name, rule, callbackCommonSpec) {
+			conflicts = append(conflicts, []string{"callbacks", name})
+		}
+	}
+
+	if auth.Response == nil {
+		return conflicts
+	}
+
+	if auth.Response.Unauthenticated != nil {
+		if !setDenyWith(authScheme, func(r *kuadrantapiv1beta2.ResponseSpec) **authorinoapi.DenyWithSpec { return &r.Unauthenticated }, auth.Response.Unauthenticated) {
+			conflicts = append(conflicts, []string{"response", "unauthenticated"})
+		}
+	}
+
+	if auth.Response.Unauthorized != nil {
+		if !setDenyWith(authScheme, func(r *kuadrantapiv1beta2.ResponseSpec) **authorinoapi.DenyWithSpec { return &r.Unauthorized }, auth.Response.Unauthorized) {
+			conflicts = append(conflicts, []string{"response", "unauthorized"})
+		}
+	}
+
+	if auth.Response.Success == nil {
+		return conflicts
+	}
+
+	if len(auth.Response.Success.Headers) == 0 && len(auth.Response.Success.DynamicMetadata) == 0 {
+		return conflicts
+	}
+
+	if authScheme.Response == nil {
+		authScheme.Response = &kuadrantapiv1beta2.ResponseSpec{}
+	}
+
+	for _, name := range utils.SortedKeys(auth.Response.Success.Headers) {
+		rule := kuadrantapiv1beta2.HeaderSuccessResponseSpec{
+			SuccessResponseSpec: kuadrantapiv1beta2.SuccessResponseSpec{
+				SuccessResponseSpec: auth.Response.Success.Headers[name],
+				CommonAuthRuleSpec:  commonSpec,
+			},
+		}
+		if !addAuthRule(&authScheme.Response.Success.Headers, name, rule, headerSuccessResponseCommonSpec) {
+			conflicts = append(conflicts, []string{"response", "success", "headers", name})
+		}
+	}
+
+	for _, name := range utils.SortedKeys(auth.Response.Success.DynamicMetadata) {
+		rule := kuadrantapiv1beta2.SuccessResponseSpec{
+			SuccessResponseSpec: auth.Response.Success.DynamicMetadata[name],
+			CommonAuthRuleSpec:  commonSpec,
+		}
+		if !addAuthRule(&authScheme.Response.Success.DynamicMetadata, name, rule, successResponseCommonSpec) {
+			conflicts = append(conflicts, []string{"response", "success", "dynamicMetadata", name})
+		}
+	}
+
+	return conflicts
+}
+
+// setDenyWith sets the denial response of the auth scheme. The denial responses are not scoped by route selectors:
+// a denial response defined by several operations must be the same for all of them. Returns false on conflict.
+func setDenyWith(authScheme *kuadrantapiv1beta2.AuthSchemeSpec, denyWith func(*kuadrantapiv1beta2.ResponseSpec) **authorinoapi.DenyWithSpec, spec *authorinoapi.DenyWithSpec) bool {
+	if authScheme.Response == nil {
+		authScheme.Response = &kuadrantapiv1beta2.ResponseSpec{}
+	}
+
+	existing := denyWith(authScheme.Response)
+	if *existing == nil {
+		*existing = spec.DeepCopy()
+		return true
+	}
+
+	return equality.Semantic.DeepEqual(*existing, spec)
+}
+
+// addAuthRule adds the rule to the rules. When a rule of the same name was added before, the route selectors
+// are merged as long as the rules are equal otherwise. Returns false on conflict.
+func addAuthRule[T any](rules *map[string]T, name string, rule T, commonSpec func(*T) *kuadrantapiv1beta2.CommonAuthRuleSpec) bool {
+	if *rules == nil {
+		*rules = make(map[string]T)
+	}
+
+	existing, ok := (*rules)[name]
+	if !ok {
+		(*rules)[name] = rule
+		return true
+	}
+
+	existingRouteSelectors := commonSpec(&existing).RouteSelectors
+	commonSpec(&existing).RouteSelectors = nil
+	routeSelectors := commonSpec(&rule).RouteSelectors
+	commonSpec(&rule).RouteSelectors = nil
+	if !equality.Semantic.DeepEqual(existing, rule) {
+		return false
+	}
+
+	commonSpec(&existing).RouteSelectors = slices.Concat(existingRouteSelectors, routeSelectors)
+	(*rules)[name] = existing
+
+	return true
+}
+
+// compactAuthRulesRouteSelectors compacts the route selectors of the rules.
+// Returns the location in the auth extension of the rules whose route selectors exceed the route selectors of an auth rule.
+func compactAuthRulesRouteSelectors[T any](location []string, rules map[string]T, commonSpec func(*T) *kuadrantapiv1beta2.CommonAuthRuleSpec) [][]string {
+	var exceeding [][]string
+
+	for _, name := range utils.SortedKeys(rules) {
+		rule := rules[name]
+		commonSpec(&rule).RouteSelectors = compactRouteSelectors(commonSpec(&rule).RouteSelectors)
+		if len(commonSpec(&rule).RouteSelectors) > MaxRouteSelectors {
+			exceeding = append(exceeding, append(slices.Clone(location), name))
+		}
+		rules[name] = rule
+	}
+
+	return exceeding
+}
+
+// authExtensionRuleLocations returns the location in the auth extension of the rules scoped by route selectors
+func authExtensionRuleLocations(auth *utils.KuadrantAuthExtension) [][]string {
+	var locations [][]string
+
+	for _, name := range utils.SortedKeys(auth.Metadata) {
+		locations = append(locations, []string{"metadata", name})
+	}
+	for _, name := range utils.SortedKeys(auth.Authorization) {
+		locations = append(locations, []string{"authorization", name})
+	}
+	for _, name := range utils.SortedKeys(auth.Callbacks) {
+		locations = append(locations, []string{"callbacks", name})
+	}
+
+	if auth.Response == nil || auth.Response.Success == nil {
+		return locations
+	}
+
+	for _, name := range utils.SortedKeys(auth.Response.Success.Headers) {
+		locations = append(locations, []string{"response", "success", "headers", name})
+	}
+	for _, name := range utils.SortedKeys(auth.Response.Success.DynamicMetadata) {
+		locations = append(locations, []string{"response", "success", "dynamicMetadata", name})
+	}
+
+	return locations
+}
+
+func metadataCommonSpec(r *kuadrantapiv1beta2.MetadataSpec) *kuadrantapiv1beta2.CommonAuthRuleSpec {
+	return &r.CommonAuthRuleSpec
+}
+
+func authorizationCommonSpec(r *kuadrantapiv1beta2.AuthorizationSpec) *kuadrantapiv1beta2.CommonAuthRuleSpec {
+	return &r.CommonAuthRuleSpec
+}
+
+func callbackCommonSpec(r *kuadrantapiv1beta2.CallbackSpec) *kuadrantapiv1beta2.CommonAuthRuleSpec {
+	return &r.CommonAuthRuleSpec
+}
+
+func headerSuccessResponseCommonSpec(r *kuadrantapiv1beta2.HeaderSuccessResponseSpec) *kuadrantapiv1beta2.CommonAuthRuleSpec {
+	return &r.CommonAuthRuleSpec
+}
+
+func successResponseCommonSpec(r *kuadrantapiv1beta2.SuccessResponseSpec) *kuadrantapiv1beta2.CommonAuthRuleSpec {
+	return &r.CommonAuthRuleSpec
+}
@@ -0,0 +1,286 @@
+package kuadrantapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	authorinoapi "github.com/kuadrant/authorino/api/v1beta2"
+	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+// Security requirements with several security schemes, all of them required (AND'ed), cannot be enforced
+// by the authentication rules alone: Authorino resolves a single identity, the first authentication rule that succeeds.
+// The operations with AND'ed security requirements get an OPA authorization rule allowing the requests
+// that satisfy any of their security requirements:
+//   - API keys and http basic credentials, verified against Secrets, are verified by the authentication.
+//     The rule checks that the resolved identity is a Secret of the security scheme. A security requirement
+//     can hold only one of them, and the JWT authentication rules are given a lower priority.
+//   - JWTs are verified by the rule itself, against the keys published by their issuer, as are their scopes.
+//   - Other security schemes, like tokens validated by introspection, cannot be verified together and are rejected.
+
+const (
+	// jwtAuthenticationPriority is the priority of the JWT authentication rules of operations with AND'ed
+	// security requirements, evaluated after the authentication rules verifying credentials against Secrets
+	jwtAuthenticationPriority = 1
+
+	oidcDiscoveryPath = "/.well-known/openid-configuration"
+
+	regoHTTPOptions = `"force_cache": true, "force_cache_duration_seconds": 3600, "raise_error": false`
+
+	regoBearerToken = `bearer_token := token {
+  [_, token] := regex.find_all_string_submatch_n("^(?i:bearer)\\s+(\\S+)$", input.context.request.http.headers.authorization, 1)[0]
+}
+
+scope_granted(claim, scope) {
+  is_array(claim)
+  claim[_] == scope
+}
+
+scope_granted(claim, scope) {
+  is_string(claim)
+  regex.split("\\s+", claim)[_] == scope
+}
+`
+
+	regoJWT = `
+# %[1]s
+%[2]s_config := response.body {
+  response := http.send({"method": "GET", "url": %[3]s, %[4]s})
+  response.status_code == 200
+}
+
+%[2]s_keys := response.raw_body {
+  response := http.send({"method": "GET", "url": %[2]s_config.jwks_uri, %[4]s})
+  response.status_code == 200
+}
+
+%[2]s := claims {
+  [valid, _, claims] := io.jwt.decode_verify(bearer_token, {"cert": %[2]s_keys, "iss": %[2]s_config.issuer})
+  valid
+}
+`
+)
+
+// securitySchemeCredentials tells how the credentials of a security scheme are verified in the operations with AND'ed security requirements
+type securitySchemeCredentials struct {
+	// secretLabel labels the Secrets the credentials are verified against by the authentication. Empty for JWTs.
+	secretLabel string
+	// discoveryURL is the OpenID Connect discovery document of the issuer of the JWTs
+	discoveryURL string
+	scopesClaim  utils.ScopesClaim
+}
+
+// andedSecurityRequirements tells whether any security requirement holds several security schemes, all of them required
+func andedSecurityRequirements(secRequirements openapi3.SecurityRequirements) bool {
+	for _, secReq := range secRequirements {
+		if len(secReq) > 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// securitySchemeCredentialsFromOAS returns how the credentials of the security scheme are verified
+// in the operations with AND'ed security requirements. False when the security scheme is not defined.
+func securitySchemeCredentialsFromOAS(doc *openapi3.T, secSchemeName string) (securitySchemeCredentials, bool, error) {
+	secScheme, ok := doc.Components.SecuritySchemes[secSchemeName]
+	if !ok || secScheme == nil || secScheme.Value == nil {
+		// should never happen. OpenAPI validation should detect this issue
+		return securitySchemeCredentials{}, false, nil
+	}
+
+	notSupported := func(reason string) error {
+		return utils.NewError(
+			utils.JSONPointer("components", "securitySchemes", secSchemeName),
+			"security scheme %q not supported in operations with security requirements of several security schemes: %s", secSchemeName, reason,
+		)
+	}
+
+	switch {
+	case secScheme.Value.Type == "apiKey":
+		return securitySchemeCredentials{secretLabel: APIKeySecretLabel}, true, nil
+	case secScheme.Value.Type == "http" && strings.EqualFold(secScheme.Value.Scheme, "basic"):
+		return securitySchemeCredentials{secretLabel: BasicAuthSecretLabel}, true, nil
+	case !tokenSecurityScheme(*secScheme.Value):
+		return securitySchemeCredentials{}, false, notSupported("only API keys, http basic credentials and JWTs can be verified together")
+	}
+
+	kuadrantSecSchemeExtension, err := utils.NewKuadrantOASSecuritySchemeExtension(secScheme.Value)
+	if err != nil {
+		return securitySchemeCredentials{}, false, utils.NewError(
+			utils.JSONPointer("components", "securitySchemes", secSchemeName, utils.KuadrantExtensionKey),
+			"invalid openapi security scheme kuadrant extension: %v", err,
+		)
+	}
+
+	issuerURL, err := jwtIssuerURL(secSchemeName, *secScheme.Value)
+	if err != nil {
+		return securitySchemeCredentials{}, false, err
+	}
+	if issuerURL == "" {
+		return securitySchemeCredentials{}, false, notSupported("tokens validated by introspection are only verified by the authentication")
+	}
+
+	return securitySchemeCredentials{
+		discoveryURL: oidcDiscoveryURL(issuerURL),
+		scopesClaim:  kuadrantSecSchemeExtension.GetScopesClaim(),
+	}, true, nil
+}
+
+// jwtIssuerURL returns the issuer of the JWTs of the token security scheme. Empty for tokens validated by introspection.
+func jwtIssuerURL(secSchemeName string, secScheme openapi3.SecurityScheme) (string, error) {
+	if secScheme.Type == "openIdConnect" {
+		return strings.TrimSuffix(secScheme.OpenIdConnectUrl, oidcDiscoveryPath), nil
+	}
+
+	authenticationSpec, err := tokenAuthenticationSpec(nil, secSchemeName, secScheme)
+	if err != nil {
+		return "", err
+	}
+	if authenticationSpec.Jwt == nil {
+		return "", nil
+	}
+
+	return authenticationSpec.Jwt.IssuerUrl, nil
+}
+
+// oidcDiscoveryURL returns the URL of the OpenID Connect discovery document of the issuer,
+// unless already the URL of the discovery document
+func oidcDiscoveryURL(issuerURL string) string {
+	if strings.HasSuffix(issuerURL, oidcDiscoveryPath) {
+		return issuerURL
+	}
+
+	return strings.TrimSuffix(issuerURL, "/") + oidcDiscoveryPath
+}
+
+// validateANDedSecurityRequirements reports the security schemes of the operation with AND'ed security requirements
+// whose credentials cannot be verified together, and the security requirements with several security schemes
+// verified against Secrets.
+func validateANDedSecurityRequirements(doc *openapi3.T, secRequirements openapi3.SecurityRequirements, pointer func(int) string) error {
+	var problems utils.Problems
+
+	for idx, secReq := range secRequirements {
+		var secretSchemeNames []string
+		for _, secSchemeName := range securitySchemeNames(secReq) {
+			credentials, ok, err := securitySchemeCredentialsFromOAS(doc, secSchemeName)
+			if err != nil {
+				problems.Append(err)
+				continue
+			}
+
+			if ok && credentials.secretLabel != "" {
+				secretSchemeNames = append(secretSchemeNames, secSchemeName)
+			}
+		}
+
+		if len(secretSchemeNames) > 1 {
+			problems.Add(utils.NewError(
+				pointer(idx),
+				"security requirement with several security schemes verified against Secrets (%s) not supported: AuthPolicy authentication resolves a single identity",
+				strings.Join(secretSchemeNames, ", "),
+			))
+		}
+	}
+
+	return problems.ErrorOrNil()
+}
+
+// buildOperationSecurityAuthorization returns the OPA authorization rule of the operation with AND'ed security requirements,
+// allowing the requests presenting valid credentials for every security scheme of any of the security requirements.
+// The security requirements are expected to be validated by validateANDedSecurityRequirements.
+func buildOperationSecurityAuthorization(doc *openapi3.T, routeSelectors []kuadrantapiv1beta2.RouteSelector, operationName string, secRequirements openapi3.SecurityRequirements) (map[string]kuadrantapiv1beta2.AuthorizationSpec, error) {
+	rego, err := securityRequirementsRego(doc, secRequirements)
+	if err != nil {
+		return nil, err
+	}
+
+	authName := fmt.Sprintf("%s_security", operationName)
+
+	return map[string]kuadrantapiv1beta2.AuthorizationSpec{
+		authName: {
+			CommonAuthRuleSpec: kuadrantapiv1beta2.CommonAuthRuleSpec{
+				RouteSelectors: routeSelectors,
+			},
+			AuthorizationSpec: authorinoapi.AuthorizationSpec{
+				AuthorizationMethodSpec: authorinoapi.AuthorizationMethodSpec{
+					Opa: &authorinoapi.OpaAuthorizationSpec{
+						Rego: rego,
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// securityRequirementsRego returns the Rego policy allowing the requests that satisfy any of the security requirements.
+// Every JWT security scheme gets a rule holding the claims of the bearer token, when verified against the keys of the issuer.
+func securityRequirementsRego(doc *openapi3.T, secRequirements openapi3.SecurityRequirements) (string, error) {
+	var rego strings.Builder
+	rego.WriteString(regoBearerToken)
+
+	credentials := make(map[string]securitySchemeCredentials)
+	jwtRules := make(map[string]string)
+	for _, secSchemeName := range securityRequirementsSchemeNames(secRequirements) {
+		schemeCredentials, ok, err := securitySchemeCredentialsFromOAS(doc, secSchemeName)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			continue
+		}
+
+		credentials[secSchemeName] = schemeCredentials
+		if schemeCredentials.secretLabel != "" {
+			continue
+		}
+
+		jwtRules[secSchemeName] = fmt.Sprintf("jwt_%d", len(jwtRules))
+		fmt.Fprintf(&rego, regoJWT, secSchemeName, jwtRules[secSchemeName], strconv.Quote(schemeCredentials.discoveryURL), regoHTTPOptions)
+	}
+
+	for idx, secReq := range secRequirements {
+		expressions := make([]string, 0, len(secReq))
+		for _, secSchemeName := range securitySchemeNames(secReq) {
+			schemeCredentials, ok := credentials[secSchemeName]
+			if !ok {
+				// security scheme not defined, the security requirement cannot be satisfied
+				expressions = nil
+				break
+			}
+
+			if schemeCredentials.secretLabel != "" {
+				expressions = append(expressions, fmt.Sprintf("input.auth.identity.metadata.labels[%s] == %s",
+					strconv.Quote(schemeCredentials.secretLabel), strconv.Quote(secSchemeName)))
+				continue
+			}
+
+			jwtRule := jwtRules[secSchemeName]
+			if len(secReq[secSchemeName]) == 0 {
+				expressions = append(expressions, jwtRule)
+				continue
+			}
+
+			for _, scope := range secReq[secSchemeName] {
+				expressions = append(expressions, fmt.Sprintf("scope_granted(%s.%s, %s)", jwtRule, schemeCredentials.scopesClaim, strconv.Quote(scope)))
+			}
+		}
+
+		switch {
+		case expressions == nil:
+			continue
+		case len(expressions) == 0:
+			// no credentials required
+			expressions = append(expressions, "true")
+		}
+
+		fmt.Fprintf(&rego, "\n# security requirement %d\nallow {\n  %s\n}\n", idx, strings.Join(expressions, "\n  "))
+	}
+
+	return rego.String(), nil
+}
@@ -0,0 +1,183 @@
+package kuadrantapi
+
+import (
+	"net/url"
+	"regexp"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	authorinoapi "github.com/kuadrant/authorino/api/v1beta2"
+	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+// oauth2EndpointSuffixRegexp matches the path of the authorization and token endpoints after the issuer path.
+// Examples:
+// https://keycloak.example.com/realms/petstore/protocol/openid-connect/token
+// https://example.okta.com/oauth2/default/v1/authorize
+// https://example.auth0.com/oauth/token
+var oauth2EndpointSuffixRegexp = regexp.MustCompile(`(?:/protocol/openid-connect|/oauth2?)?(?:/v[0-9]+)?/(?:token|authorize|auth)/?$`)
+
+// tokenAuthenticationSpec returns the authentication of the bearer tokens of the oauth2 and http bearer security schemes.
+// The tokens are validated as JWTs, or by token introspection, as set in the security scheme kuadrant extension.
+// The issuer and the introspection endpoint of oauth2 security schemes default to values derived from the flow URLs.
+func tokenAuthenticationSpec(routeSelectors []kuadrantapiv1beta2.RouteSelector, secSchemeName string, secScheme openapi3.SecurityScheme) (kuadrantapiv1beta2.AuthenticationSpec, error) {
+	extensionPointer := utils.JSONPointer("components", "securitySchemes", secSchemeName, utils.KuadrantExtensionKey)
+
+	kuadrantSecSchemeExtension, err := utils.NewKuadrantOASSecuritySchemeExtension(&secScheme)
+	if err != nil {
+		return kuadrantapiv1beta2.AuthenticationSpec{}, utils.NewError(
+			extensionPointer, "invalid openapi security scheme kuadrant extension: %v", err,
+		)
+	}
+
+	authenticationSpec := kuadrantapiv1beta2.AuthenticationSpec{
+		CommonAuthRuleSpec: kuadrantapiv1beta2.CommonAuthRuleSpec{
+			RouteSelectors: routeSelectors,
+		},
+	}
+
+	switch kuadrantSecSchemeExtension.GetTokenValidation() {
+	case utils.OAuth2TokenValidationIntrospection:
+		introspection := kuadrantSecSchemeExtension.Introspection
+		if introspection == nil || introspection.CredentialsRef == "" {
+			return kuadrantapiv1beta2.AuthenticationSpec{}, utils.NewError(
+				extensionPointer, "token introspection requires the kuadrant extension introspection credentialsRef",
+			)
+		}
+
+		introspectionURL := ""
+		if tokenURL := oauth2TokenURL(secScheme.Flows); tokenURL != "" {
+			introspectionURL = tokenURL + "/introspect"
+		}
+		introspectionURL = ptr.Deref(introspection.URL, introspectionURL)
+		if introspectionURL == "" {
+			if secScheme.Type == "http" {
+				return kuadrantapiv1beta2.AuthenticationSpec{}, utils.NewError(
+					extensionPointer, "token introspection endpoint required by http bearer security schemes: set the kuadrant extension introspection url",
+				)
+			}
+			return kuadrantapiv1beta2.AuthenticationSpec{}, utils.NewError(
+				extensionPointer, "token introspection endpoint cannot be derived, no oauth2 flow with tokenUrl: set the kuadrant extension introspection url",
+			)
+		}
+
+		authenticationSpec.AuthenticationMethodSpec = authorinoapi.AuthenticationMethodSpec{
+			OAuth2TokenIntrospection: &authorinoapi.OAuth2TokenIntrospectionSpec{
+				Url:         introspectionURL,
+				Credentials: &corev1.LocalObjectReference{Name: introspection.CredentialsRef},
+			},
+		}
+	default:
+		issuerURL := tokenIssuerURL(secScheme, kuadrantSecSchemeExtension)
+		if issuerURL == "" {
+			if secScheme.Type == "http" {
+				// http bearer security schemes have no flow the issuer could be derived from
+				return kuadrantapiv1beta2.AuthenticationSpec{}, utils.NewError(
+					extensionPointer, "token issuer required by http bearer security schemes: set the kuadrant extension issuerUrl, "+
+						"or the kuadrant extension tokenValidation introspection and introspection url",
+				)
+			}
+			return kuadrantapiv1beta2.AuthenticationSpec{}, utils.NewError(
+				extensionPointer, "token issuer cannot be derived, no oauth2 flow URL: set the kuadrant extension issuerUrl",
+			)
+		}
+
+		authenticationSpec.AuthenticationMethodSpec = authorinoapi.AuthenticationMethodSpec{
+			Jwt: &authorinoapi.JwtAuthenticationSpec{
+				IssuerUrl: issuerURL,
+			},
+		}
+	}
+
+	return authenticationSpec, nil
+}
+
+// tokenIssuerURL returns the issuer of the JWTs of the oauth2 and http bearer security schemes,
+// set in the security scheme kuadrant extension or derived from the oauth2 flow URLs
+func tokenIssuerURL(secScheme openapi3.SecurityScheme, kuadrantSecSchemeExtension *utils.KuadrantOASSecuritySchemeExtension) string {
+	return ptr.Deref(kuadrantSecSchemeExtension.IssuerURL, oauth2IssuerURL(secScheme.Flows))
+}
+
+// basicAuthenticationSpec returns the authentication of the http basic security scheme.
+// The credentials are validated like API keys, against the base64 encoded user:password
+// stored in the Secrets labelled with the security scheme name.
+func basicAuthenticationSpec(routeSelectors []kuadrantapiv1beta2.RouteSelector, secSchemeName string) kuadrantapiv1beta2.AuthenticationSpec {
+	return kuadrantapiv1beta2.AuthenticationSpec{
+		CommonAuthRuleSpec: kuadrantapiv1beta2.CommonAuthRuleSpec{
+			RouteSelectors: routeSelectors,
+		},
+		AuthenticationSpec: authorinoapi.AuthenticationSpec{
+			Credentials: authorinoapi.Credentials{
+				AuthorizationHeader: &authorinoapi.Prefixed{Prefix: "Basic"},
+			},
+			AuthenticationMethodSpec: authorinoapi.AuthenticationMethodSpec{
+				ApiKey: &authorinoapi.ApiKeyAuthenticationSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							// label selector be like
+							// kuadrant.io/basic-auth-by: ${SecuritySchemeName}
+							BasicAuthSecretLabel: secSchemeName,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// oauth2Flows returns the flows of the security scheme, the flows with a token endpoint first
+func oauth2Flows(flows *openapi3.OAuthFlows) []*openapi3.OAuthFlow {
+	if flows == nil {
+		return nil
+	}
+
+	var result []*openapi3.OAuthFlow
+	for _, flow := range []*openapi3.OAuthFlow{flows.AuthorizationCode, flows.ClientCredentials, flows.Password, flows.Implicit} {
+		if flow != nil {
+			result = append(result, flow)
+		}
+	}
+
+	return result
+}
+
+// oauth2TokenURL returns the token endpoint of the first flow defining one
+func oauth2TokenURL(flows *openapi3.OAuthFlows) string {
+	for _, flow := range oauth2Flows(flows) {
+		if flow.TokenURL != "" {
+			return flow.TokenURL
+		}
+	}
+
+	return ""
+}
+
+// oauth2IssuerURL returns the issuer derived from the token or authorization endpoint of the first flow.
+// The endpoint path is removed from the URL, as well as the query and fragment.
+func oauth2IssuerURL(flows *openapi3.OAuthFlows) string {
+	for _, flow := range oauth2Flows(flows) {
+		for _, endpoint := range []string{flow.TokenURL, flow.AuthorizationURL} {
+			if endpoint == "" {
+				continue
+			}
+
+			endpointURL, err := url.Parse(endpoint)
+			if err != nil || endpointURL.Host == "" {
+				continue
+			}
+
+			endpointURL.Path = oauth2EndpointSuffixRegexp.ReplaceAllString(endpointURL.Path, "")
+			endpointURL.RawPath = ""
+			endpointURL.RawQuery = ""
+			endpointURL.Fragment = ""
+
+			return endpointURL.String()
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,190 @@
+package kuadrantapi
+
+import (
+	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+	"k8s.io/apimachinery/pkg/api/equality"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/kuadrantctl/pkg/utils"
+)
+
+// AuthPoliciesForHTTPRoutes splits the AuthPolicy in one policy per HTTPRoute, when the rules are split in several HTTPRoutes.
+// Each policy is named after its target HTTPRoute and keeps the route selectors and auth rules matching the rules of the route.
+// Policies selecting no rule of their HTTPRoute are skipped: without top level route selectors,
+// the policy would apply to every rule of the HTTPRoute.
+func AuthPoliciesForHTTPRoutes(ap *kuadrantapiv1beta2.AuthPolicy, httpRoutes []*gatewayapiv1.HTTPRoute) []*kuadrantapiv1beta2.AuthPolicy {
+	if len(httpRoutes) < 2 {
+		return []*kuadrantapiv1beta2.AuthPolicy{ap}
+	}
+
+	policies := make([]*kuadrantapiv1beta2.AuthPolicy, 0, len(httpRoutes))
+
+	for _, httpRoute := range httpRoutes {
+		routePolicy := ap.DeepCopy()
+		routePolicy.Name = httpRoute.Name
+		routePolicy.Spec.TargetRef.Name = gatewayapiv1.ObjectName(httpRoute.Name)
+		routePolicy.Spec.RouteSelectors = compactRouteSelectors(routeSelectorsForHTTPRoute(routePolicy.Spec.RouteSelectors, httpRoute))
+
+		if len(routePolicy.Spec.RouteSelectors) == 0 {
+			// no secured operation routed by the HTTPRoute
+			continue
+		}
+
+		if authScheme := routePolicy.Spec.AuthScheme; authScheme != nil {
+			authScheme.Authentication = authRulesForHTTPRoute(authScheme.Authentication, httpRoute,
+				func(r *kuadrantapiv1beta2.AuthenticationSpec) *kuadrantapiv1beta2.CommonAuthRuleSpec {
+					return &r.CommonAuthRuleSpec
+				})
+			authScheme.Metadata = authRulesForHTTPRoute(authScheme.Metadata, httpRoute,
+				func(r *kuadrantapiv1beta2.MetadataSpec) *kuadrantapiv1beta2.CommonAuthRuleSpec {
+					return &r.CommonAuthRuleSpec
+				})
+			authScheme.Authorization = authRulesForHTTPRoute(authScheme.Authorization, httpRoute,
+				func(r *kuadrantapiv1beta2.AuthorizationSpec) *kuadrantapiv1beta2.CommonAuthRuleSpec {
+					return &r.CommonAuthRuleSpec
+				})
+			authScheme.Callbacks = authRulesForHTTPRoute(authScheme.Callbacks, httpRoute,
+				func(r *kuadrantapiv1beta2.CallbackSpec) *kuadrantapiv1beta2.CommonAuthRuleSpec {
+					return &r.CommonAuthRuleSpec
+				})
+
+			if authScheme.Response != nil {
+				authScheme.Response.Success.Headers = authRulesForHTTPRoute(authScheme.Response.Success.Headers, httpRoute,
+					func(r *kuadrantapiv1beta2.HeaderSuccessResponseSpec) *kuadrantapiv1beta2.CommonAuthRuleSpec {
+						return &r.CommonAuthRuleSpec
+					})
+				authScheme.Response.Success.DynamicMetadata = authRulesForHTTPRoute(authScheme.Response.Success.DynamicMetadata, httpRoute,
+					func(r *kuadrantapiv1beta2.SuccessResponseSpec) *kuadrantapiv1beta2.CommonAuthRuleSpec {
+						return &r.CommonAuthRuleSpec
+					})
+			}
+		}
+
+		policies = append(policies, routePolicy)
+	}
+
+	return policies
+}
+
+// CheckAuthPolicyRouteSelectors reports the policies with more top level route selectors
+// than the AuthPolicy CRD validation accepts
+func CheckAuthPolicyRouteSelectors(policies []*kuadrantapiv1beta2.AuthPolicy) error {
+	var problems utils.Problems
+
+	for _, policy := range policies {
+		if len(policy.Spec.RouteSelectors) > MaxPolicyRouteSelectors {
+			problems.Add(utils.NewError(
+				utils.JSONPointer("paths"),
+				"AuthPolicy %q selects the secured operations with %d route selectors, more than the %d route selectors allowed",
+				policy.Name, len(policy.Spec.RouteSelectors), MaxPolicyRouteSelectors,
+			))
+		}
+	}
+
+	return problems.ErrorOrNil()
+}
+
+// authRulesForHTTPRoute returns the auth rules applying to the whole route, or selecting rules of the HTTPRoute.
+// The route selectors of the rules are narrowed to the rules of the HTTPRoute.
+func authRulesForHTTPRoute[T any](rules map[string]T, httpRoute *gatewayapiv1.HTTPRoute, commonSpec func(*T) *kuadrantapiv1beta2.CommonAuthRuleSpec) map[string]T {
+	selected := make(map[string]T)
+	for name, rule := range rules {
+		if len(commonSpec(&rule).RouteSelectors) == 0 {
+			// applies to the whole route
+			selected[name] = rule
+			continue
+		}
+
+		commonSpec(&rule).RouteSelectors = routeSelectorsForHTTPRoute(commonSpec(&rule).RouteSelectors, httpRoute)
+		if len(commonSpec(&rule).RouteSelectors) > 0 {
+			selected[name] = rule
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil
+	}
+
+	return selected
+}
+
+// RateLimitPoliciesForHTTPRoutes splits the RateLimitPolicy in one policy per HTTPRoute, when the rules are split in several HTTPRoutes.
+// Each policy is named after its target HTTPRoute and keeps the limits matching the rules of the route.
+// Policies without limits are skipped.
+func RateLimitPoliciesForHTTPRoutes(rlp *kuadrantapiv1beta2.RateLimitPolicy, httpRoutes []*gatewayapiv1.HTTPRoute) []*kuadrantapiv1beta2.RateLimitPolicy {
+	if len(httpRoutes) < 2 {
+		return []*kuadrantapiv1beta2.RateLimitPolicy{rlp}
+	}
+
+	policies := make([]*kuadrantapiv1beta2.RateLimitPolicy, 0, len(httpRoutes))
+
+	for _, httpRoute := range httpRoutes {
+		routePolicy := rlp.DeepCopy()
+		routePolicy.Name = httpRoute.Name
+		routePolicy.Spec.TargetRef.Name = gatewayapiv1.ObjectName(httpRoute.Name)
+
+		limits := make(map[string]kuadrantapiv1beta2.Limit)
+		for name, limit := range routePolicy.Spec.Limits {
+			if len(limit.RouteSelectors) == 0 {
+				// applies to the whole route
+				limits[name] = limit
+				continue
+			}
+
+			limit.RouteSelectors = routeSelectorsForHTTPRoute(limit.RouteSelectors, httpRoute)
+			if len(limit.RouteSelectors) > 0 {
+				limits[name] = limit
+			}
+		}
+
+		if len(limits) == 0 {
+			// no rate limited operation routed by the HTTPRoute
+			continue
+		}
+		routePolicy.Spec.Limits = limits
+
+		policies = append(policies, routePolicy)
+	}
+
+	return policies
+}
+
+// routeSelectorsForHTTPRoute returns the route selectors narrowed to the matches of the HTTPRoute rules.
+// The matches of a route selector are OR'ed: route selectors keeping no match are dropped.
+func routeSelectorsForHTTPRoute(routeSelectors []kuadrantapiv1beta2.RouteSelector, httpRoute *gatewayapiv1.HTTPRoute) []kuadrantapiv1beta2.RouteSelector {
+	var selected []kuadrantapiv1beta2.RouteSelector
+
+	for _, routeSelector := range routeSelectors {
+		if len(routeSelector.Matches) == 0 {
+			// selects every rule
+			selected = append(selected, routeSelector)
+			continue
+		}
+
+		var matches []gatewayapiv1.HTTPRouteMatch
+		for _, selectorMatch := range routeSelector.Matches {
+			if httpRouteHasMatch(httpRoute, selectorMatch) {
+				matches = append(matches, selectorMatch)
+			}
+		}
+
+		if len(matches) > 0 {
+			routeSelector.Matches = matches
+			selected = append(selected, routeSelector)
+		}
+	}
+
+	return selected
+}
+
+func httpRouteHasMatch(httpRoute *gatewayapiv1.HTTPRoute, selectorMatch gatewayapiv1.HTTPRouteMatch) bool {
+	for _, rule := range httpRoute.Spec.Rules {
+		for _, match := range rule.Matches {
+			if equality.Semantic.DeepEqual(selectorMatch, match) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
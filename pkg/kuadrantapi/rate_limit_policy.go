@@ -1,93 +1,182 @@
 package kuadrantapi
 
 import (
+	"slices"
+
 	"github.com/getkin/kin-openapi/openapi3"
 	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/utils/ptr"
 	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	"github.com/kuadrant/kuadrantctl/pkg/gatewayapi"
 	"github.com/kuadrant/kuadrantctl/pkg/utils"
 )
 
-func RateLimitPolicyObjectMetaFromOAS(doc *openapi3.T) metav1.ObjectMeta {
+func RateLimitPolicyObjectMetaFromOAS(doc *openapi3.T) (metav1.ObjectMeta, error) {
 	return gatewayapi.HTTPRouteObjectMetaFromOAS(doc)
 }
 
-func RateLimitPolicyLimitsFromOAS(doc *openapi3.T) map[string]kuadrantapiv1beta2.Limit {
-	// Current implementation, one limit per operation
-	// TODO(eguzki): consider about grouping operations in fewer RLP limits
-
+// RateLimitPolicyLimitsFromOAS returns one limit per operation, named after the operation, or per rate limit name.
+// Operations with the same rate limit name share the limit, which must be the same for all of them.
+// With rate limit grouping, operations with identical unnamed rate limits share the limit of the first operation.
+func RateLimitPolicyLimitsFromOAS(doc *openapi3.T, opts *utils.GenerateOptions) (map[string]kuadrantapiv1beta2.Limit, error) {
 	limits := make(map[string]kuadrantapiv1beta2.Limit)
+	// location of the first rate limit of every limit
+	limitPointers := make(map[string]string)
+	// limits of the unnamed rate limits, candidates to grouping
+	var unnamedLimits []string
+	// limits of the named rate limits
+	namedLimits := make(map[string]bool)
+
+	var problems utils.Problems
 
-	basePath, err := utils.BasePathFromOpenAPI(doc)
+	rootExtension, err := utils.KuadrantOASRootExtensionFromOAS(doc)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	if err := utils.ValidateServerVariables(doc, opts); err != nil {
+		return nil, err
 	}
 
-	// Paths
-	for path, pathItem := range doc.Paths {
-		kuadrantPathExtension, err := utils.NewKuadrantOASPathExtension(pathItem)
+	// Operations, sorted by path and method
+	for _, oasOperation := range utils.OperationsFromOAS(doc, opts) {
+		path, pathItem, verb, operation := oasOperation.Path, oasOperation.PathItem, oasOperation.Verb, oasOperation.Operation
+
+		// root, path and operation level kuadrant extensions
+		kuadrantExtension, err := utils.NewKuadrantOASEffectiveExtension(rootExtension, oasOperation)
 		if err != nil {
-			panic(err)
+			problems.Append(err)
+			continue
 		}
 
-		// Operations
-		for verb, operation := range pathItem.Operations() {
-			kuadrantOperationExtension, err := utils.NewKuadrantOASOperationExtension(operation)
-			if err != nil {
-				panic(err)
-			}
+		if kuadrantExtension.IsDisabled() {
+			// not enabled for the operation
+			continue
+		}
+
+		// servers may be overridden at the path and operation levels
+		basePath, err := utils.OperationBasePathFromOAS(doc, oasOperation, opts)
+		if err != nil {
+			problems.Append(err)
+			continue
+		}
+
+		rateLimit, rateLimitPointer := kuadrantExtension.RateLimit, kuadrantExtension.RateLimitPointer
+
+		if rateLimit == nil {
+			// no rate limit defined for this operation
+			//fmt.Printf("OUT no rate limit defined: path: %s, method: %s\n", path, verb)
+			continue
+		}
+
+		pathMatchType := kuadrantExtension.GetPathMatchType()
+
+		routeSelectors, err := buildLimitRouteSelectors(basePath, path, pathItem, verb, operation, pathMatchType, opts)
+		if err != nil {
+			problems.Append(err)
+			continue
+		}
+
+		limit := kuadrantapiv1beta2.Limit{
+			RouteSelectors: routeSelectors,
+			When:           rateLimit.When,
+			Counters:       rateLimit.Counters,
+			Rates:          rateLimit.Rates,
+		}
 
-			if ptr.Deref(kuadrantOperationExtension.Disable, kuadrantPathExtension.IsDisabled()) {
-				// not enabled for the operation
-				//fmt.Printf("OUT not enabled: path: %s, method: %s\n", path, verb)
+		limitName := rateLimit.Name
+		if limitName == "" {
+			limitName = utils.OpenAPIOperationName(path, verb, operation)
+			if opts.GetGroupRateLimits() {
+				if idx := slices.IndexFunc(unnamedLimits, func(name string) bool {
+					return sameLimitDefinition(limits[name], limit)
+				}); idx != -1 {
+					limitName = unnamedLimits[idx]
+				}
+			}
+			if namedLimits[limitName] {
+				// the named rate limit is reported
+				problems.Add(namedLimitClash(limitPointers[limitName], limitName))
 				continue
 			}
-
-			// default backendrefs at the path level
-			rateLimit := kuadrantPathExtension.RateLimit
-			if kuadrantOperationExtension.RateLimit != nil {
-				rateLimit = kuadrantOperationExtension.RateLimit
+			if !slices.Contains(unnamedLimits, limitName) {
+				unnamedLimits = append(unnamedLimits, limitName)
 			}
-
-			if rateLimit == nil {
-				// no rate limit defined for this operation
-				//fmt.Printf("OUT no rate limit defined: path: %s, method: %s\n", path, verb)
+		} else {
+			if slices.Contains(unnamedLimits, limitName) {
+				problems.Add(namedLimitClash(rateLimitPointer, limitName))
 				continue
 			}
+			namedLimits[limitName] = true
+		}
 
-			// default pathMatchType at the path level
-			pathMatchType := ptr.Deref(
-				kuadrantOperationExtension.PathMatchType,
-				kuadrantPathExtension.GetPathMatchType(),
-			)
+		existing, ok := limits[limitName]
+		if !ok {
+			limits[limitName] = limit
+			limitPointers[limitName] = rateLimitPointer
+			continue
+		}
 
-			limitName := utils.OpenAPIOperationName(path, verb, operation)
+		if !sameLimitDefinition(existing, limit) {
+			problems.Add(utils.NewError(
+				rateLimitPointer,
+				"rate limit %q differs from the rate limit of the same name of another operation", limitName,
+			))
+			continue
+		}
 
-			limits[limitName] = kuadrantapiv1beta2.Limit{
-				RouteSelectors: buildLimitRouteSelectors(basePath, path, pathItem, verb, operation, pathMatchType),
-				When:           rateLimit.When,
-				Counters:       rateLimit.Counters,
-				Rates:          rateLimit.Rates,
-			}
+		existing.RouteSelectors = append(existing.RouteSelectors, limit.RouteSelectors...)
+		limits[limitName] = existing
+	}
+
+	for name, limit := range limits {
+		limit.RouteSelectors = compactRouteSelectors(limit.RouteSelectors)
+		if len(limit.RouteSelectors) > MaxLimitRouteSelectors {
+			problems.Add(utils.NewError(
+				limitPointers[name],
+				"rate limit %q selects more operations than fit in %d route selectors of %d matches", name, MaxLimitRouteSelectors, MaxRouteSelectorMatches,
+			))
 		}
+		limits[name] = limit
+	}
+
+	if err := problems.ErrorOrNil(); err != nil {
+		return nil, err
 	}
 
 	if len(limits) == 0 {
-		return nil
+		return nil, nil
 	}
 
-	return limits
+	return limits, nil
 }
 
-func buildLimitRouteSelectors(basePath, path string, pathItem *openapi3.PathItem, verb string, op *openapi3.Operation, pathMatchType gatewayapiv1.PathMatchType) []kuadrantapiv1beta2.RouteSelector {
-	match := utils.OpenAPIMatcherFromOASOperations(basePath, path, pathItem, verb, op, pathMatchType)
+// namedLimitClash reports a rate limit name used as well by the limit of unnamed rate limits, named after an operation
+func namedLimitClash(rateLimitPointer, limitName string) utils.Problem {
+	return utils.NewError(
+		rateLimitPointer,
+		"rate limit name %q clashes with the limit named after the operation %q, whose rate limit has no name", limitName, limitName,
+	)
+}
+
+func buildLimitRouteSelectors(basePath, path string, pathItem *openapi3.PathItem, verb string, op *openapi3.Operation, pathMatchType gatewayapiv1.PathMatchType, opts *utils.GenerateOptions) ([]kuadrantapiv1beta2.RouteSelector, error) {
+	match, err := utils.OpenAPIMatcherFromOASOperations(basePath, path, pathItem, verb, op, pathMatchType, opts)
+	if err != nil {
+		return nil, err
+	}
 
 	return []kuadrantapiv1beta2.RouteSelector{
 		{
 			Matches: []gatewayapiv1.HTTPRouteMatch{match},
 		},
-	}
+	}, nil
+}
+
+// sameLimitDefinition tells whether both limits have the same conditions, counters and rates
+func sameLimitDefinition(a, b kuadrantapiv1beta2.Limit) bool {
+	return equality.Semantic.DeepEqual(a.When, b.When) &&
+		equality.Semantic.DeepEqual(a.Counters, b.Counters) &&
+		equality.Semantic.DeepEqual(a.Rates, b.Rates)
 }
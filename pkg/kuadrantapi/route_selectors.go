@@ -0,0 +1,64 @@
+package kuadrantapi
+
+import (
+	"fmt"
+
+	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// Kuadrant limits, enforced by the policy CRD validation
+const (
+	MaxRouteSelectors       = 8
+	MaxRouteSelectorMatches = 8
+	MaxLimitRouteSelectors  = 15
+	MaxPolicyRouteSelectors = 15
+)
+
+// compactRouteSelectors merges the matches of the route selectors without hostnames
+// in route selectors of up to MaxRouteSelectorMatches matches.
+// The matches of a route selector are OR'ed, the selection is unchanged.
+func compactRouteSelectors(routeSelectors []kuadrantapiv1beta2.RouteSelector) []kuadrantapiv1beta2.RouteSelector {
+	compacted := make([]kuadrantapiv1beta2.RouteSelector, 0, len(routeSelectors))
+
+	var matches []gatewayapiv1.HTTPRouteMatch
+	for _, routeSelector := range routeSelectors {
+		if len(routeSelector.Hostnames) > 0 || len(routeSelector.Matches) == 0 {
+			compacted = append(compacted, routeSelector)
+			continue
+		}
+
+		matches = append(matches, routeSelector.Matches...)
+	}
+
+	for len(matches) > 0 {
+		size := min(len(matches), MaxRouteSelectorMatches)
+		compacted = append(compacted, kuadrantapiv1beta2.RouteSelector{Matches: matches[:size]})
+		matches = matches[size:]
+	}
+
+	return compacted
+}
+
+// splitRouteSelectors splits the route selectors in groups of up to MaxRouteSelectors route selectors, one group per rule.
+// There is always at least one group, even without route selectors.
+func splitRouteSelectors(routeSelectors []kuadrantapiv1beta2.RouteSelector) [][]kuadrantapiv1beta2.RouteSelector {
+	groups := [][]kuadrantapiv1beta2.RouteSelector{}
+
+	for len(routeSelectors) > MaxRouteSelectors {
+		groups = append(groups, routeSelectors[:MaxRouteSelectors])
+		routeSelectors = routeSelectors[MaxRouteSelectors:]
+	}
+
+	return append(groups, routeSelectors)
+}
+
+// splitAuthRuleName returns the name of the auth rule holding the idx-th group of route selectors.
+// The first rule keeps the name, the next ones are suffixed with their position.
+func splitAuthRuleName(name string, idx int) string {
+	if idx == 0 {
+		return name
+	}
+
+	return fmt.Sprintf("%s-%d", name, idx+1)
+}
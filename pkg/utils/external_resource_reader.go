@@ -16,23 +16,161 @@ limitations under the License.
 package utils
 
 import (
+	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 )
 
-// ReadExternalResource reads data streams from external resources. Currently implemented:
+// localSchemes are the URL schemes of the resources local to the user: the filesystem, local git repositories
+// and the cluster of the current kubeconfig context
+var localSchemes = []string{"file", GitFileScheme, ConfigMapScheme, SecretScheme}
+
+// ResourceReader reads the resource located by the URL
+type ResourceReader func(location *url.URL) ([]byte, error)
+
+// ExternalResourceReader reads data streams from external resources. Currently implemented:
 // - '-' or '@' for STDIN
-// - URLs (HTTP[S])
-// - Files
-func ReadExternalResource(resource string) ([]byte, error) {
+// - URLs of the schemes with a registered reader: by default HTTP[S] and git+file
+// - Files, or file URLs
+type ExternalResourceReader struct {
+	readers map[string]ResourceReader
+}
+
+// NewExternalResourceReader returns the reader of the external resources with the default schemes registered
+func NewExternalResourceReader(httpOpts *HTTPOptions) *ExternalResourceReader {
+	r := &ExternalResourceReader{readers: map[string]ResourceReader{}}
+
+	readHTTP := func(location *url.URL) ([]byte, error) { return ReadURL(location, httpOpts) }
+	r.Register("http", readHTTP)
+	r.Register("https", readHTTP)
+	r.Register(GitFileScheme, ReadGitFile)
+
+	return r
+}
+
+// Register sets the reader of the URLs of the scheme, replacing the previous one if any
+func (r *ExternalResourceReader) Register(scheme string, reader ResourceReader) {
+	r.readers[scheme] = reader
+}
+
+// Schemes returns the sorted URL schemes with a registered reader
+func (r *ExternalResourceReader) Schemes() []string {
+	schemes := make([]string, 0, len(r.readers))
+	for scheme := range r.readers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Read reads the external resource
+func (r *ExternalResourceReader) Read(resource string) ([]byte, error) {
 	if resource == "-" || resource == "@" {
 		return io.ReadAll(os.Stdin)
 	}
 
-	if url, isURL := ParseURL(resource); isURL {
-		return ReadURL(url)
+	location, err := r.resourceURL(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	if location != nil {
+		return r.ReadLocation(location)
 	}
 
 	// Defaulting to filepath
 	return os.ReadFile(resource)
 }
+
+// ReadLocation reads the resource located by the URL.
+// URLs without scheme and file URLs are read from the filesystem.
+func (r *ExternalResourceReader) ReadLocation(location *url.URL) ([]byte, error) {
+	if location.Scheme == "" || location.Scheme == "file" {
+		if location.Host != "" {
+			return nil, fmt.Errorf("unsupported file URL %q with host", location.String())
+		}
+		return os.ReadFile(filepath.FromSlash(location.Path))
+	}
+
+	reader, ok := r.readers[location.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported URL scheme %q, supported schemes: %v", location.Scheme, r.Schemes())
+	}
+
+	return reader(location)
+}
+
+// Location returns the location of the external resource, from which
+// the relative references of the resource are resolved.
+// Nil for STDIN: relative references are resolved from the working directory.
+func (r *ExternalResourceReader) Location(resource string) (*url.URL, error) {
+	if resource == "-" || resource == "@" {
+		return nil, nil
+	}
+
+	location, err := r.resourceURL(resource)
+	if err != nil || location != nil {
+		return location, err
+	}
+
+	path, err := filepath.Abs(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	return &url.URL{Path: filepath.ToSlash(path)}, nil
+}
+
+// resourceURL returns the URL of the resource, nil for files
+func (r *ExternalResourceReader) resourceURL(resource string) (*url.URL, error) {
+	location, err := url.Parse(resource)
+	if err != nil || location.Scheme == "" {
+		return nil, nil
+	}
+
+	if _, ok := r.readers[location.Scheme]; ok || location.Scheme == "file" {
+		return location, nil
+	}
+
+	if location.Host != "" {
+		return nil, fmt.Errorf("unsupported URL scheme %q, supported schemes: %v", location.Scheme, r.Schemes())
+	}
+
+	// like Windows paths with a drive letter
+	return nil, nil
+}
+
+// CheckExternalReference returns an error when the document, located by the URL, is not allowed
+// to reference the resource located by the reference URL:
+// - documents of local schemes only reference local resources of the same scheme, or remote resources
+// - remote documents only reference resources of their own scheme and origin
+// A nil document location, like for STDIN, is a file.
+func CheckExternalReference(document, reference *url.URL) error {
+	documentScheme, referenceScheme := "file", "file"
+	if document != nil && document.Scheme != "" {
+		documentScheme = strings.ToLower(document.Scheme)
+	}
+	if reference.Scheme != "" {
+		referenceScheme = strings.ToLower(reference.Scheme)
+	}
+
+	if !slices.Contains(localSchemes, documentScheme) {
+		if referenceScheme != documentScheme || !strings.EqualFold(reference.Host, document.Host) {
+			return fmt.Errorf("external reference %q not allowed: the remote document %q only references resources of its origin %s://%s",
+				reference.String(), document.String(), documentScheme, document.Host)
+		}
+		return nil
+	}
+
+	if slices.Contains(localSchemes, referenceScheme) && referenceScheme != documentScheme {
+		return fmt.Errorf("external reference %q not allowed: %s resources only referenced from %s documents",
+			reference.String(), referenceScheme, referenceScheme)
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("ExternalResourceReader", func() {
+	It("reads the URLs with the reader registered for their scheme", func() {
+		reader := NewExternalResourceReader(nil)
+		reader.Register("mem", func(location *url.URL) ([]byte, error) {
+			return []byte(location.Host + location.Path), nil
+		})
+
+		data, err := reader.Read("mem://specs/petstore.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("specs/petstore.yaml"))
+
+		location, err := reader.Location("mem://specs/petstore.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(location.String()).To(Equal("mem://specs/petstore.yaml"))
+
+		_, err = reader.Read("ftp://specs/petstore.yaml")
+		Expect(err).To(MatchError(`unsupported URL scheme "ftp", supported schemes: [git+file http https mem]`))
+	})
+
+	It("reads files and file URLs", func() {
+		file := filepath.Join(GinkgoT().TempDir(), "petstore.yaml")
+		Expect(os.WriteFile(file, []byte("openapi: 3.0.2"), 0o600)).To(Succeed())
+
+		reader := NewExternalResourceReader(nil)
+		for _, resource := range []string{file, "file://" + filepath.ToSlash(file)} {
+			data, err := reader.Read(resource)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("openapi: 3.0.2"))
+		}
+	})
+})
+
+var _ = Describe("ReadGitFile", func() {
+	var repo string
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		Expect(err).ToNot(HaveOccurred(), string(out))
+	}
+
+	BeforeEach(func() {
+		if _, err := exec.LookPath("git"); err != nil {
+			Skip("git command not found")
+		}
+
+		repo = GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(repo, "apis"), 0o755)).To(Succeed())
+		git("init", "-q")
+
+		Expect(os.WriteFile(filepath.Join(repo, "apis", "petstore.yaml"), []byte("v1"), 0o600)).To(Succeed())
+		git("add", "-A")
+		git("commit", "-q", "-m", "v1")
+		git("tag", "v1")
+
+		Expect(os.WriteFile(filepath.Join(repo, "apis", "petstore.yaml"), []byte("v2"), 0o600)).To(Succeed())
+		git("commit", "-q", "-a", "-m", "v2")
+	})
+
+	DescribeTable("reads the file at the ref",
+		func(rawURL func() string, expected string) {
+			location, err := url.Parse(rawURL())
+			Expect(err).ToNot(HaveOccurred())
+
+			data, err := ReadGitFile(location)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(expected))
+		},
+		Entry("HEAD by default", func() string { return "git+file://" + filepath.ToSlash(repo) + "//apis/petstore.yaml" }, "v2"),
+		Entry("tag", func() string { return "git+file://" + filepath.ToSlash(repo) + "//apis/petstore.yaml?ref=v1" }, "v1"),
+		Entry("repository found from the file path", func() string {
+			return "git+file://" + filepath.ToSlash(repo) + "/apis/petstore.yaml?ref=v1"
+		}, "v1"),
+	)
+
+	It("reports the missing files and invalid refs", func() {
+		location, err := url.Parse("git+file://" + filepath.ToSlash(repo) + "//apis/missing.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = ReadGitFile(location)
+		Expect(err).To(MatchError(ContainSubstring("reading apis/missing.yaml at HEAD from git repository")))
+
+		location.RawQuery = "ref=--output=/tmp/out"
+		_, err = ReadGitFile(location)
+		Expect(err).To(MatchError(`invalid git ref "--output=/tmp/out"`))
+	})
+})
+
+var _ = DescribeTable("CheckExternalReference",
+	func(document, reference, expectedErr string) {
+		var documentURL *url.URL
+		if document != "" {
+			var err error
+			documentURL, err = url.Parse(document)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		referenceURL, err := url.Parse(reference)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = CheckExternalReference(documentURL, referenceURL)
+		if expectedErr == "" {
+			Expect(err).ToNot(HaveOccurred())
+		} else {
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+		}
+	},
+	Entry("remote document, same origin", "https://example.com/apis/petstore.yaml", "https://example.com/components.yaml", ""),
+	Entry("remote document, other host", "https://example.com/apis/petstore.yaml", "https://example.org/components.yaml",
+		`the remote document "https://example.com/apis/petstore.yaml" only references resources of its origin https://example.com`),
+	Entry("remote document, other scheme", "https://example.com/apis/petstore.yaml", "http://example.com/components.yaml",
+		"only references resources of its origin https://example.com"),
+	Entry("remote document, file", "https://example.com/apis/petstore.yaml", "file:///etc/passwd",
+		"only references resources of its origin https://example.com"),
+	Entry("remote document, secret", "https://example.com/apis/petstore.yaml", "secret://apis/petstore/token",
+		"only references resources of its origin https://example.com"),
+	Entry("file", "/apis/petstore.yaml", "/apis/components.yaml", ""),
+	Entry("file, remote reference", "/apis/petstore.yaml", "https://example.com/components.yaml", ""),
+	Entry("file, git file", "/apis/petstore.yaml", "git+file:///repo//components.yaml",
+		"git+file resources only referenced from git+file documents"),
+	Entry("STDIN, file", "", "/apis/components.yaml", ""),
+	Entry("STDIN, ConfigMap", "", "configmap://apis/petstore/components.yaml",
+		"configmap resources only referenced from configmap documents"),
+	Entry("ConfigMap", "configmap://apis/petstore/openapi.yaml", "configmap://apis/petstore/components.yaml", ""),
+	Entry("ConfigMap, Secret", "configmap://apis/petstore/openapi.yaml", "secret://apis/petstore/token",
+		"secret resources only referenced from secret documents"),
+	Entry("git file, file", "git+file:///repo//petstore.yaml", "file:///etc/passwd",
+		"file resources only referenced from file documents"),
+)
+
+var _ = Describe("Kubernetes resource readers", func() {
+	var getClient KubernetesClientFunc
+
+	BeforeEach(func() {
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "petstore", Namespace: "apis"},
+				Data:       map[string]string{"openapi.yaml": "openapi: 3.0.2"},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "petstore", Namespace: "apis"},
+				Data:       map[string][]byte{"openapi.yaml": []byte("openapi: 3.1.0")},
+			},
+		).Build()
+		getClient = func() (client.Reader, error) { return k8sClient, nil }
+	})
+
+	It("reads the ConfigMap and Secret keys", func() {
+		reader := NewExternalResourceReader(nil)
+		reader.Register(ConfigMapScheme, ConfigMapResourceReader(context.Background(), getClient))
+		reader.Register(SecretScheme, SecretResourceReader(context.Background(), getClient))
+
+		data, err := reader.Read("configmap://apis/petstore/openapi.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("openapi: 3.0.2"))
+
+		data, err = reader.Read("secret://apis/petstore/openapi.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("openapi: 3.1.0"))
+
+		_, err = reader.Read("configmap://apis/petstore/missing.yaml")
+		Expect(err).To(MatchError(`key "missing.yaml" not found in ConfigMap apis/petstore`))
+
+		_, err = reader.Read("secret://apis/petstore")
+		Expect(err).To(MatchError(`invalid secret URL "secret://apis/petstore", expected secret://NAMESPACE/NAME/KEY`))
+	})
+})
@@ -0,0 +1,108 @@
+package utils
+
+// GenerateOptions holds the user provided settings shared by the OpenAPI based generators.
+// A nil *GenerateOptions is valid and stands for the default settings.
+type GenerateOptions struct {
+	// OperationOrder defines the order of the generated rules. Default: alphabetical
+	OperationOrder OperationOrder
+	// SpecOrder records the position of paths and operations in the source document.
+	// Required by the spec operation order. Optional.
+	SpecOrder *SpecOrder
+	// WarningHandler is called for every construct of the OpenAPI document ignored by the generators. Optional.
+	WarningHandler func(Problem)
+	// ServerIndex selects the server of the OpenAPI document by index. Default: the first server
+	ServerIndex *int
+	// ServerURL selects the server of the OpenAPI document by URL, as written in the document or rendered.
+	// Mutually exclusive with ServerIndex.
+	ServerURL string
+	// ServerVariables overrides the default values of the server variables
+	ServerVariables map[string]string
+	// HostnamesFromServers fills the route hostnames from the hosts of the server URLs
+	// when the kuadrant extension does not define them
+	HostnamesFromServers bool
+	// CompactRules groups the operations with the same backendRefs and filters in HTTPRoute rules
+	// and splits the rules in several HTTPRoutes when the Gateway API limits are exceeded
+	CompactRules bool
+	// PathTemplateMatch defines how templated paths are matched. Default: regex
+	PathTemplateMatch PathTemplateMatch
+	// AuthenticationPerOperation generates one AuthPolicy authentication rule per operation and security scheme.
+	// Default: one authentication rule per security scheme, selecting every operation secured by the scheme
+	AuthenticationPerOperation bool
+	// GroupRateLimits groups the operations with identical unnamed rate limits in one limit, sharing the counters
+	GroupRateLimits bool
+}
+
+func (o *GenerateOptions) GetOperationOrder() OperationOrder {
+	if o == nil || o.OperationOrder == "" {
+		// Set default
+		return OperationOrderAlphabetical
+	}
+
+	return o.OperationOrder
+}
+
+func (o *GenerateOptions) GetPathTemplateMatch() PathTemplateMatch {
+	if o == nil || o.PathTemplateMatch == "" {
+		// Set default
+		return PathTemplateMatchRegex
+	}
+
+	return o.PathTemplateMatch
+}
+
+func (o *GenerateOptions) GetSpecOrder() *SpecOrder {
+	if o == nil || o.SpecOrder == nil {
+		return &SpecOrder{}
+	}
+
+	return o.SpecOrder
+}
+
+// Warn reports a construct of the OpenAPI document ignored by the generators
+func (o *GenerateOptions) Warn(problem Problem) {
+	if o == nil || o.WarningHandler == nil {
+		return
+	}
+
+	o.WarningHandler(problem)
+}
+
+func (o *GenerateOptions) GetServerIndex() *int {
+	if o == nil {
+		return nil
+	}
+
+	return o.ServerIndex
+}
+
+func (o *GenerateOptions) GetServerURL() string {
+	if o == nil {
+		return ""
+	}
+
+	return o.ServerURL
+}
+
+func (o *GenerateOptions) GetServerVariables() map[string]string {
+	if o == nil {
+		return nil
+	}
+
+	return o.ServerVariables
+}
+
+func (o *GenerateOptions) GetHostnamesFromServers() bool {
+	return o != nil && o.HostnamesFromServers
+}
+
+func (o *GenerateOptions) GetCompactRules() bool {
+	return o != nil && o.CompactRules
+}
+
+func (o *GenerateOptions) GetAuthenticationPerOperation() bool {
+	return o != nil && o.AuthenticationPerOperation
+}
+
+func (o *GenerateOptions) GetGroupRateLimits() bool {
+	return o != nil && o.GroupRateLimits
+}
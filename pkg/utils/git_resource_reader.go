@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// GitFileScheme is the URL scheme of the files of local git repositories
+const GitFileScheme = "git+file"
+
+// ReadGitFile reads a file of a local git repository, located by a
+// git+file:///path/to/repo//path/to/file?ref=REF URL.
+// The ref defaults to HEAD. The double slash separating the repository from the file is optional:
+// when missing, the repository is the closest parent directory holding a git repository.
+// The git command is required.
+func ReadGitFile(location *url.URL) ([]byte, error) {
+	if location.Host != "" {
+		return nil, fmt.Errorf("unsupported %s URL %q with host", GitFileScheme, location.String())
+	}
+
+	repo, file, err := splitGitFilePath(location.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := location.Query().Get("ref")
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid git ref %q", ref)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", "-C", filepath.FromSlash(repo), "show", ref+":"+file)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("reading %s at %s from git repository %s: %w: %s",
+			file, ref, repo, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// splitGitFilePath returns the repository and the path of the file within the repository
+func splitGitFilePath(p string) (string, string, error) {
+	if repo, file, found := strings.Cut(p, "//"); found && repo != "" {
+		return repo, path.Clean(file), nil
+	}
+
+	for dir := path.Dir(p); ; dir = path.Dir(dir) {
+		if isGitRepository(filepath.FromSlash(dir)) {
+			return dir, strings.TrimPrefix(p, strings.TrimSuffix(dir, "/")+"/"), nil
+		}
+
+		if dir == "/" || dir == "." {
+			return "", "", fmt.Errorf("no git repository found for %s", p)
+		}
+	}
+}
+
+// isGitRepository returns true for working trees and bare repositories
+func isGitRepository(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return true
+	}
+
+	head, headErr := os.Stat(filepath.Join(dir, "HEAD"))
+	objects, objectsErr := os.Stat(filepath.Join(dir, "objects"))
+	return headErr == nil && objectsErr == nil && !head.IsDir() && objects.IsDir()
+}
@@ -16,26 +16,270 @@ limitations under the License.
 package utils
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"time"
 )
 
+const (
+	DefaultHTTPTimeout = 30 * time.Second
+	DefaultHTTPMaxSize = 32 << 20
+	DefaultHTTPRetries = 2
+)
+
+// httpRetryBackoff is the wait before the first retry, doubled for every retry
+var httpRetryBackoff = 500 * time.Millisecond
+
+// HTTPOptions configures how resources are read from HTTP[S] URLs.
+// A nil *HTTPOptions is valid and stands for the default settings.
+type HTTPOptions struct {
+	// Timeout of every request. Default: DefaultHTTPTimeout
+	Timeout time.Duration
+	// MaxSize of the responses, in bytes. Default: DefaultHTTPMaxSize
+	MaxSize int64
+	// Retries of the requests failing with a network error or a 429 or 5xx status. Default: DefaultHTTPRetries
+	Retries *int
+	// BearerToken sent in the Authorization header. Optional.
+	BearerToken string
+	// Username and Password of the basic authentication, when no bearer token is set. Optional.
+	Username string
+	Password string
+	// CredentialsHost is the host the credentials are sent to. Default: the credentials are not sent
+	CredentialsHost string
+	// CAFile is the PEM bundle of the certificate authorities trusted besides the system ones. Optional.
+	CAFile string
+	// InsecureSkipTLSVerify disables the verification of the server certificates
+	InsecureSkipTLSVerify bool
+	// CacheDir stores the responses with an ETag, revalidated by the next requests. Default: no cache
+	CacheDir string
+}
+
+func (o *HTTPOptions) GetTimeout() time.Duration {
+	if o == nil || o.Timeout == 0 {
+		return DefaultHTTPTimeout
+	}
+
+	return o.Timeout
+}
+
+func (o *HTTPOptions) GetMaxSize() int64 {
+	if o == nil || o.MaxSize == 0 {
+		return DefaultHTTPMaxSize
+	}
+
+	return o.MaxSize
+}
+
+func (o *HTTPOptions) GetRetries() int {
+	if o == nil || o.Retries == nil {
+		return DefaultHTTPRetries
+	}
+
+	return *o.Retries
+}
+
+func (o *HTTPOptions) GetCacheDir() string {
+	if o == nil {
+		return ""
+	}
+
+	return o.CacheDir
+}
+
 // ParseURL returns true when valid HTTP[S] url is found
 func ParseURL(str string) (*url.URL, bool) {
 	u, err := url.Parse(str)
 	return u, err == nil && u.Scheme != "" && u.Host != ""
 }
 
-func ReadURL(location *url.URL) ([]byte, error) {
-	resp, err := http.Get(location.String())
+// ReadURL reads the resource from the HTTP[S] URL.
+// Responses with a status other than 2xx are errors. Transient failures are retried.
+func ReadURL(location *url.URL, opts *HTTPOptions) ([]byte, error) {
+	client, err := opts.client()
 	if err != nil {
 		return nil, err
 	}
-	data, err := io.ReadAll(resp.Body)
+
+	cache := newHTTPCache(opts.GetCacheDir(), location, opts.authorization(location))
+
+	for attempt := 0; ; attempt++ {
+		data, retry, err := readURLOnce(client, location, opts, cache)
+		if err == nil {
+			return data, nil
+		}
+
+		if !retry || attempt >= opts.GetRetries() {
+			return nil, fmt.Errorf("reading %s: %w", location.Redacted(), err)
+		}
+
+		time.Sleep(httpRetryBackoff << attempt)
+	}
+}
+
+// readURLOnce sends one request. Returns whether the error is transient.
+func readURLOnce(client *http.Client, location *url.URL, opts *HTTPOptions, cache *httpCache) ([]byte, bool, error) {
+	req, err := http.NewRequest(http.MethodGet, location.String(), nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	opts.setCredentials(req)
+
+	etag, cached := cache.read()
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
 	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		return cached, false, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		transient := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return nil, transient, fmt.Errorf("unexpected HTTP status %q", resp.Status)
+	}
+
+	maxSize := opts.GetMaxSize()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
 	if err != nil {
-		return nil, err
+		return nil, true, err
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, false, fmt.Errorf("response larger than the maximum size of %d bytes", maxSize)
+	}
+
+	cache.write(resp.Header.Get("ETag"), data)
+
+	return data, false, nil
+}
+
+func (o *HTTPOptions) client() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if o != nil && (o.CAFile != "" || o.InsecureSkipTLSVerify) {
+		tlsConfig := &tls.Config{InsecureSkipVerify: o.InsecureSkipTLSVerify} //nolint:gosec // requested by the user
+
+		if o.CAFile != "" {
+			pem, err := os.ReadFile(o.CAFile)
+			if err != nil {
+				return nil, err
+			}
+
+			rootCAs, err := x509.SystemCertPool()
+			if err != nil {
+				rootCAs = x509.NewCertPool()
+			}
+
+			if !rootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificate found in CA file %s", o.CAFile)
+			}
+			tlsConfig.RootCAs = rootCAs
+		}
+
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &http.Client{Transport: transport, Timeout: o.GetTimeout()}, nil
+}
+
+func (o *HTTPOptions) setCredentials(req *http.Request) {
+	if authorization := o.authorization(req.URL); authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+}
+
+// authorization returns the Authorization header of the requests to the URL. Empty when no credentials are sent.
+func (o *HTTPOptions) authorization(location *url.URL) string {
+	if o == nil || o.CredentialsHost == "" || o.CredentialsHost != location.Host {
+		return ""
+	}
+
+	if o.BearerToken != "" {
+		return "Bearer " + o.BearerToken
+	}
+
+	if o.Username != "" || o.Password != "" {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(o.Username+":"+o.Password))
+	}
+
+	return ""
+}
+
+// httpCache is the cached response of a URL, along with its ETag
+type httpCache struct {
+	etagPath, dataPath string
+}
+
+// newHTTPCache returns the cache of the URL read with the Authorization header.
+// The responses read with other credentials, or none, are cached apart.
+func newHTTPCache(dir string, location *url.URL, authorization string) *httpCache {
+	if dir == "" {
+		return nil
+	}
+
+	sum := sha256.Sum256([]byte(location.String() + "\n" + authorization))
+	key := hex.EncodeToString(sum[:])
+
+	return &httpCache{
+		etagPath: filepath.Join(dir, key+".etag"),
+		dataPath: filepath.Join(dir, key+".data"),
+	}
+}
+
+// read returns the cached ETag and response. Empty ETag when not cached.
+func (c *httpCache) read() (string, []byte) {
+	if c == nil {
+		return "", nil
+	}
+
+	etag, err := os.ReadFile(c.etagPath)
+	if err != nil {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(c.dataPath)
+	if err != nil {
+		return "", nil
+	}
+
+	return string(etag), data
+}
+
+// write caches the response. Caching is best effort: failures are ignored.
+func (c *httpCache) write(etag string, data []byte) {
+	if c == nil {
+		return
+	}
+
+	if etag == "" {
+		// not cacheable
+		_ = os.Remove(c.etagPath)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.dataPath), 0o755); err != nil {
+		return
+	}
+
+	// the data first, an ETag without data is never used
+	if err := os.WriteFile(c.dataPath, data, 0o600); err != nil {
+		return
 	}
-	return data, nil
+	_ = os.WriteFile(c.etagPath, []byte(etag), 0o600)
 }
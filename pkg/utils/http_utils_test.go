@@ -16,6 +16,12 @@ limitations under the License.
 package utils
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -35,3 +41,144 @@ var _ = DescribeTable("IsURL",
 	Entry("only schema", "testing-path.yaml", false),
 	Entry("only schema", "alskjff#?asf//dfas", false),
 )
+
+var _ = Describe("ReadURL", func() {
+	var (
+		server   *httptest.Server
+		handler  http.HandlerFunc
+		location *url.URL
+	)
+
+	BeforeEach(func() {
+		DeferCleanup(func(backoff time.Duration) { httpRetryBackoff = backoff }, httpRetryBackoff)
+		httpRetryBackoff = time.Millisecond
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler(w, r)
+		}))
+		DeferCleanup(server.Close)
+
+		var err error
+		location, err = url.Parse(server.URL + "/openapi.yaml")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("rejects responses with a non 2xx status", func() {
+		requests := 0
+		handler = func(w http.ResponseWriter, _ *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("<html>not found</html>"))
+		}
+
+		_, err := ReadURL(location, nil)
+		Expect(err).To(MatchError(ContainSubstring(`unexpected HTTP status "404 Not Found"`)))
+		Expect(requests).To(Equal(1))
+	})
+
+	It("retries transient failures", func() {
+		requests := 0
+		handler = func(w http.ResponseWriter, _ *http.Request) {
+			requests++
+			if requests < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte("openapi: 3.0.2"))
+		}
+
+		data, err := ReadURL(location, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("openapi: 3.0.2"))
+		Expect(requests).To(Equal(3))
+
+		requests = 0
+		retries := 1
+		_, err = ReadURL(location, &HTTPOptions{Retries: &retries})
+		Expect(err).To(MatchError(ContainSubstring("503")))
+		Expect(requests).To(Equal(2))
+	})
+
+	It("rejects responses larger than the maximum size", func() {
+		handler = func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(strings.Repeat("a", 11)))
+		}
+
+		_, err := ReadURL(location, &HTTPOptions{MaxSize: 10})
+		Expect(err).To(MatchError(ContainSubstring("maximum size of 10 bytes")))
+
+		data, err := ReadURL(location, &HTTPOptions{MaxSize: 11})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(HaveLen(11))
+	})
+
+	It("sends the credentials to the credentials host", func() {
+		var authorization string
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+		}
+
+		_, err := ReadURL(location, &HTTPOptions{BearerToken: "secret", CredentialsHost: location.Host})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authorization).To(Equal("Bearer secret"))
+
+		_, err = ReadURL(location, &HTTPOptions{Username: "user", Password: "pass", CredentialsHost: location.Host})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authorization).To(Equal("Basic dXNlcjpwYXNz"))
+
+		_, err = ReadURL(location, &HTTPOptions{BearerToken: "secret", CredentialsHost: "registry.example.com"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authorization).To(BeEmpty())
+
+		// without credentials host
+		_, err = ReadURL(location, &HTTPOptions{BearerToken: "secret"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authorization).To(BeEmpty())
+	})
+
+	It("revalidates the cached responses with their ETag", func() {
+		requests := 0
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte("openapi: 3.0.2"))
+		}
+
+		opts := &HTTPOptions{CacheDir: GinkgoT().TempDir()}
+
+		for range 2 {
+			data, err := ReadURL(location, opts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("openapi: 3.0.2"))
+		}
+		Expect(requests).To(Equal(2))
+	})
+
+	It("caches apart the responses read with other credentials", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte("openapi: 3.0.2"))
+		}
+
+		cacheDir := GinkgoT().TempDir()
+
+		_, err := ReadURL(location, &HTTPOptions{BearerToken: "secret", CredentialsHost: location.Host, CacheDir: cacheDir})
+		Expect(err).ToNot(HaveOccurred())
+
+		// the response cached with the credentials is not read without them
+		_, err = ReadURL(location, &HTTPOptions{CacheDir: cacheDir})
+		Expect(err).To(MatchError(ContainSubstring("401")))
+	})
+})
@@ -0,0 +1,84 @@
+package utils
+
+// KuadrantOASEffectiveExtension is the kuadrant extension applying to an operation.
+// Every setting of the operation-level extension overrides the path-level one,
+// which overrides the root-level one.
+type KuadrantOASEffectiveExtension struct {
+	KuadrantOASPathExtension
+
+	// RateLimitPointer locates the effective rate limit in the OpenAPI document
+	RateLimitPointer string
+	// AuthPointer locates the effective auth rules in the OpenAPI document
+	AuthPointer string
+}
+
+// NewKuadrantOASEffectiveExtension resolves the kuadrant extension of the operation
+// from the root, path and operation-level kuadrant extensions.
+// The root extension, see KuadrantOASRootExtensionFromOAS, may be nil.
+func NewKuadrantOASEffectiveExtension(rootExtension *KuadrantOASRootExtension, oasOperation OASOperation) (*KuadrantOASEffectiveExtension, error) {
+	path, verb := oasOperation.Path, oasOperation.Verb
+
+	pathExtension, err := NewKuadrantOASPathExtension(oasOperation.PathItem)
+	if err != nil {
+		return nil, NewError(
+			JSONPointer("paths", path, KuadrantExtensionKey),
+			"invalid openapi path kuadrant extension: %v", err,
+		)
+	}
+
+	operationExtension, err := NewKuadrantOASOperationExtension(oasOperation.Operation)
+	if err != nil {
+		return nil, NewError(
+			OperationJSONPointer(path, verb, KuadrantExtensionKey),
+			"invalid openapi operation kuadrant extension: %v", err,
+		)
+	}
+
+	effective := &KuadrantOASEffectiveExtension{}
+
+	if rootExtension != nil {
+		effective.override(&KuadrantOASPathExtension{
+			Disable:       rootExtension.Disable,
+			PathMatchType: rootExtension.PathMatchType,
+			BackendRefs:   rootExtension.BackendRefs,
+			RateLimit:     rootExtension.RateLimit,
+		}, func(tokens ...string) string {
+			return JSONPointer(append([]string{KuadrantExtensionKey}, tokens...)...)
+		})
+	}
+
+	effective.override(pathExtension, func(tokens ...string) string {
+		return JSONPointer(append([]string{"paths", path, KuadrantExtensionKey}, tokens...)...)
+	})
+
+	effective.override((*KuadrantOASPathExtension)(operationExtension), func(tokens ...string) string {
+		return OperationJSONPointer(path, verb, append([]string{KuadrantExtensionKey}, tokens...)...)
+	})
+
+	return effective, nil
+}
+
+// override replaces the settings defined by the extension, located by pointer
+func (k *KuadrantOASEffectiveExtension) override(extension *KuadrantOASPathExtension, pointer func(tokens ...string) string) {
+	if extension.Disable != nil {
+		k.Disable = extension.Disable
+	}
+
+	if extension.PathMatchType != nil {
+		k.PathMatchType = extension.PathMatchType
+	}
+
+	if len(extension.BackendRefs) > 0 {
+		k.BackendRefs = extension.BackendRefs
+	}
+
+	if extension.RateLimit != nil {
+		k.RateLimit = extension.RateLimit
+		k.RateLimitPointer = pointer("rate_limit")
+	}
+
+	if extension.Auth != nil {
+		k.Auth = extension.Auth
+		k.AuthPointer = pointer("auth")
+	}
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("NewKuadrantOASEffectiveExtension", func() {
+	var doc *openapi3.T
+
+	BeforeEach(func() {
+		var err error
+		doc, err = openapi3.NewLoader().LoadFromData([]byte(`
+openapi: "3.0.3"
+info:
+  title: "Pet Store API"
+  version: "1.0.0"
+x-kuadrant:
+  route:
+    name: petstore
+  pathMatchType: PathPrefix
+  backendRefs:
+    - name: petstore
+      port: 80
+  rate_limit:
+    rates:
+      - limit: 10
+        duration: 1
+        unit: minute
+paths:
+  /cat:
+    x-kuadrant:
+      disable: true
+      backendRefs:
+        - name: cats
+          port: 80
+    get:
+      x-kuadrant:
+        disable: false
+        rate_limit:
+          rates:
+            - limit: 1
+              duration: 1
+              unit: second
+      responses:
+        405:
+          description: "invalid input"
+    post:
+      responses:
+        405:
+          description: "invalid input"
+  /dog:
+    get:
+      responses:
+        405:
+          description: "invalid input"
+`))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	rootExtension := func() *KuadrantOASRootExtension {
+		rootExtension, err := KuadrantOASRootExtensionFromOAS(doc)
+		Expect(err).ToNot(HaveOccurred())
+		return rootExtension
+	}
+
+	operation := func(path, verb string) OASOperation {
+		pathItem := doc.Paths.Find(path)
+		return OASOperation{Path: path, PathItem: pathItem, Verb: verb, Operation: pathItem.GetOperation(verb)}
+	}
+
+	It("root settings inherited", func() {
+		extension, err := NewKuadrantOASEffectiveExtension(rootExtension(), operation("/dog", "GET"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extension.IsDisabled()).To(BeFalse())
+		Expect(extension.GetPathMatchType()).To(Equal(gatewayapiv1.PathMatchPathPrefix))
+		Expect(extension.BackendRefs[0].Name).To(Equal(gatewayapiv1.ObjectName("petstore")))
+		Expect(extension.RateLimit.Rates[0].Limit).To(Equal(10))
+		Expect(extension.RateLimitPointer).To(Equal("#/x-kuadrant/rate_limit"))
+	})
+
+	It("path settings override root settings", func() {
+		extension, err := NewKuadrantOASEffectiveExtension(rootExtension(), operation("/cat", "POST"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extension.IsDisabled()).To(BeTrue())
+		Expect(extension.BackendRefs[0].Name).To(Equal(gatewayapiv1.ObjectName("cats")))
+		Expect(extension.PathMatchType).To(Equal(ptr.To(gatewayapiv1.PathMatchPathPrefix)))
+	})
+
+	It("operation settings override path settings", func() {
+		extension, err := NewKuadrantOASEffectiveExtension(rootExtension(), operation("/cat", "GET"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extension.IsDisabled()).To(BeFalse())
+		Expect(extension.BackendRefs[0].Name).To(Equal(gatewayapiv1.ObjectName("cats")))
+		Expect(extension.RateLimit.Rates[0].Limit).To(Equal(1))
+		Expect(extension.RateLimitPointer).To(Equal("#/paths/~1cat/get/x-kuadrant/rate_limit"))
+	})
+})
@@ -0,0 +1,197 @@
+package utils
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+	// KuadrantOASExtensionsSchemaID is the identifier of the JSON Schema of the kuadrant extensions
+	KuadrantOASExtensionsSchemaID = "https://raw.githubusercontent.com/Kuadrant/kuadrantctl/main/pkg/utils/kuadrant_oas_extensions.schema.json"
+)
+
+// KuadrantOASExtensionsSchema is the JSON Schema of the kuadrant extensions as generated by KuadrantOASExtensionsJSONSchema.
+// Kept up to date with `make schema`.
+//
+//go:embed kuadrant_oas_extensions.schema.json
+var KuadrantOASExtensionsSchema []byte
+
+// jsonSchema is the subset of the JSON Schema (draft-07) keywords needed to describe the kuadrant extensions
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	PatternProperties    map[string]*jsonSchema `json:"patternProperties,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Definitions          map[string]*jsonSchema `json:"definitions,omitempty"`
+}
+
+// KuadrantOASExtensionsJSONSchema generates the JSON Schema of the kuadrant extensions from the Go types
+// the extensions are unmarshalled to.
+// The schema describes an OpenAPI document: the root, path, operation, parameter and security scheme kuadrant extensions
+// are validated and any other content is allowed. The extensions are also available as definitions
+// to be referenced by other schemas. Like the lint command, unknown fields of the extensions are not allowed.
+func KuadrantOASExtensionsJSONSchema() ([]byte, error) {
+	generator := &jsonSchemaGenerator{definitions: map[string]*jsonSchema{}, definitionTypes: map[string]reflect.Type{}}
+
+	rootRef := generator.schemaOf(reflect.TypeOf(KuadrantOASRootExtension{}))
+	pathRef := generator.schemaOf(reflect.TypeOf(KuadrantOASPathExtension{}))
+	operationRef := generator.schemaOf(reflect.TypeOf(KuadrantOASOperationExtension{}))
+	parameterRef := generator.schemaOf(reflect.TypeOf(KuadrantOASParameterExtension{}))
+	securitySchemeRef := generator.schemaOf(reflect.TypeOf(KuadrantOASSecuritySchemeExtension{}))
+	if generator.err != nil {
+		return nil, generator.err
+	}
+
+	// OpenAPI objects holding kuadrant extensions
+	generator.definitions["openapi3.Parameter"] = &jsonSchema{
+		Type:       "object",
+		Properties: map[string]*jsonSchema{KuadrantExtensionKey: parameterRef},
+	}
+	parameters := &jsonSchema{
+		Type:  "array",
+		Items: &jsonSchema{Ref: "#/definitions/openapi3.Parameter"},
+	}
+	generator.definitions["openapi3.SecurityScheme"] = &jsonSchema{
+		Type:       "object",
+		Properties: map[string]*jsonSchema{KuadrantExtensionKey: securitySchemeRef},
+	}
+	generator.definitions["openapi3.Operation"] = &jsonSchema{
+		Type:       "object",
+		Properties: map[string]*jsonSchema{KuadrantExtensionKey: operationRef, "parameters": parameters},
+	}
+	pathItem := &jsonSchema{
+		Type:       "object",
+		Properties: map[string]*jsonSchema{KuadrantExtensionKey: pathRef, "parameters": parameters},
+	}
+	for _, verb := range []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"} {
+		pathItem.Properties[verb] = &jsonSchema{Ref: "#/definitions/openapi3.Operation"}
+	}
+	generator.definitions["openapi3.PathItem"] = pathItem
+
+	schema := &jsonSchema{
+		Schema:      jsonSchemaDraft,
+		ID:          KuadrantOASExtensionsSchemaID,
+		Title:       "OpenAPI document with Kuadrant extensions",
+		Description: "Validates the x-kuadrant extensions of an OpenAPI 3.x document. Generated by kuadrantctl, do not edit.",
+		Type:        "object",
+		Properties: map[string]*jsonSchema{
+			KuadrantExtensionKey: rootRef,
+			"paths": {
+				Type: "object",
+				PatternProperties: map[string]*jsonSchema{
+					"^/": {Ref: "#/definitions/openapi3.PathItem"},
+				},
+			},
+			"components": {
+				Type: "object",
+				Properties: map[string]*jsonSchema{
+					"parameters": {
+						Type:                 "object",
+						AdditionalProperties: &jsonSchema{Ref: "#/definitions/openapi3.Parameter"},
+					},
+					"securitySchemes": {
+						Type:                 "object",
+						AdditionalProperties: &jsonSchema{Ref: "#/definitions/openapi3.SecurityScheme"},
+					},
+				},
+			},
+		},
+		Definitions: generator.definitions,
+	}
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+type jsonSchemaGenerator struct {
+	definitions map[string]*jsonSchema
+	// types of the definitions, two types must not share a definition name
+	definitionTypes map[string]reflect.Type
+	err             error
+}
+
+// schemaOf returns the schema of the values encoding/json unmarshals into the type.
+// Named struct types are added to the definitions and referenced.
+func (g *jsonSchemaGenerator) schemaOf(t reflect.Type) *jsonSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	// Types with custom unmarshalling accept any value
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return &jsonSchema{}
+	}
+
+	if enumValues, ok := KuadrantOASExtensionEnums[t]; ok {
+		return &jsonSchema{Type: "string", Enum: enumValues}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+
+		name := jsonSchemaDefinitionName(t)
+		if definitionType, ok := g.definitionTypes[name]; ok && definitionType != t && g.err == nil {
+			g.err = fmt.Errorf("types %s and %s share the JSON Schema definition name %q",
+				definitionType.PkgPath()+"."+definitionType.Name(), t.PkgPath()+"."+t.Name(), name)
+		}
+		g.definitionTypes[name] = t
+		if _, ok := g.definitions[name]; !ok {
+			// Placeholder for recursive types
+			g.definitions[name] = nil
+			g.definitions[name] = g.structSchema(t)
+		}
+		return &jsonSchema{Ref: fmt.Sprintf("#/definitions/%s", name)}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Interface:
+		return &jsonSchema{}
+	default:
+		return &jsonSchema{Type: jsonTypeName(t)}
+	}
+}
+
+func (g *jsonSchemaGenerator) structSchema(t reflect.Type) *jsonSchema {
+	schema := &jsonSchema{
+		Type:                 "object",
+		Properties:           map[string]*jsonSchema{},
+		AdditionalProperties: false,
+	}
+
+	for _, field := range jsonFieldsOf(t) {
+		schema.Properties[field.name] = g.schemaOf(field.typ)
+	}
+
+	return schema
+}
+
+// jsonSchemaDefinitionName returns the name of the type qualified by its import path without the host,
+// packages of different modules share their last element, like the v1beta2 APIs of Authorino and Kuadrant.
+// Example: gateway-api.apis.v1.HTTPBackendRef
+func jsonSchemaDefinitionName(t reflect.Type) string {
+	elements := strings.Split(t.PkgPath(), "/")
+	if len(elements) > 1 && strings.Contains(elements[0], ".") {
+		elements = elements[1:]
+	}
+	return strings.Join(append(elements, t.Name()), ".")
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+
+	authorinoapi "github.com/kuadrant/authorino/api/v1beta2"
+	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("KuadrantOASExtensionsJSONSchema", func() {
+	var schema map[string]any
+
+	BeforeEach(func() {
+		data, err := KuadrantOASExtensionsJSONSchema()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(json.Unmarshal(data, &schema)).To(Succeed())
+	})
+
+	It("embedded schema is up to date, run `make schema` otherwise", func() {
+		data, err := KuadrantOASExtensionsJSONSchema()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(KuadrantOASExtensionsSchema)).To(Equal(string(data)))
+	})
+
+	It("extensions are referenced at root, path and operation levels", func() {
+		Expect(schema).To(HaveKeyWithValue("properties", HaveKeyWithValue("x-kuadrant",
+			HaveKeyWithValue("$ref", "#/definitions/kuadrant.kuadrantctl.pkg.utils.KuadrantOASRootExtension"),
+		)))
+		Expect(schema).To(HaveKeyWithValue("definitions", SatisfyAll(
+			HaveKeyWithValue("openapi3.PathItem", HaveKeyWithValue("properties", HaveKeyWithValue("x-kuadrant",
+				HaveKeyWithValue("$ref", "#/definitions/kuadrant.kuadrantctl.pkg.utils.KuadrantOASPathExtension"),
+			))),
+			HaveKeyWithValue("openapi3.Operation", HaveKeyWithValue("properties", HaveKeyWithValue("x-kuadrant",
+				HaveKeyWithValue("$ref", "#/definitions/kuadrant.kuadrantctl.pkg.utils.KuadrantOASOperationExtension"),
+			))),
+		)))
+	})
+
+	It("extension fields follow the json tags and unknown fields are not allowed", func() {
+		definitions := schema["definitions"].(map[string]any)
+		pathExtension := definitions["kuadrant.kuadrantctl.pkg.utils.KuadrantOASPathExtension"].(map[string]any)
+		Expect(pathExtension).To(HaveKeyWithValue("additionalProperties", false))
+		Expect(pathExtension["properties"]).To(SatisfyAll(
+			HaveKeyWithValue("disable", HaveKeyWithValue("type", "boolean")),
+			HaveKeyWithValue("pathMatchType", HaveKeyWithValue("enum", ConsistOf("Exact", "PathPrefix", "RegularExpression"))),
+			HaveKeyWithValue("backendRefs", HaveKeyWithValue("items", HaveKeyWithValue("$ref", "#/definitions/gateway-api.apis.v1.HTTPBackendRef"))),
+			HaveKeyWithValue("rate_limit", HaveKeyWithValue("$ref", "#/definitions/kuadrant.kuadrantctl.pkg.utils.KuadrantRateLimitExtension")),
+		))
+	})
+
+	It("fields of embedded structs are promoted", func() {
+		definitions := schema["definitions"].(map[string]any)
+		backendRef := definitions["gateway-api.apis.v1.HTTPBackendRef"].(map[string]any)
+		Expect(backendRef["properties"]).To(SatisfyAll(
+			HaveKey("name"), HaveKey("port"), HaveKey("weight"), HaveKey("filters"),
+		))
+	})
+
+	It("types of packages sharing their name get different definitions", func() {
+		Expect(jsonSchemaDefinitionName(reflect.TypeOf(corev1.LocalObjectReference{}))).To(Equal("api.core.v1.LocalObjectReference"))
+		Expect(jsonSchemaDefinitionName(reflect.TypeOf(gatewayapiv1.LocalObjectReference{}))).To(Equal("gateway-api.apis.v1.LocalObjectReference"))
+		Expect(jsonSchemaDefinitionName(reflect.TypeOf(authorinoapi.AuthorizationSpec{}))).To(Equal("kuadrant.authorino.api.v1beta2.AuthorizationSpec"))
+		Expect(jsonSchemaDefinitionName(reflect.TypeOf(kuadrantapiv1beta2.AuthorizationSpec{}))).To(Equal("kuadrant.kuadrant-operator.api.v1beta2.AuthorizationSpec"))
+	})
+})
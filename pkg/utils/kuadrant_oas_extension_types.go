@@ -2,14 +2,53 @@ package utils
 
 import (
 	"encoding/json"
+	"reflect"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"k8s.io/utils/ptr"
 	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 
+	authorinoapi "github.com/kuadrant/authorino/api/v1beta2"
 	kuadrantapiv1beta2 "github.com/kuadrant/kuadrant-operator/api/v1beta2"
 )
 
+const (
+	// KuadrantExtensionKey is the name of the Kuadrant OpenAPI specification extension
+	KuadrantExtensionKey = "x-kuadrant"
+)
+
+// KuadrantOASExtensionEnums lists the valid values of the enumerated types used by the kuadrant extensions
+var KuadrantOASExtensionEnums = map[reflect.Type][]string{
+	reflect.TypeOf(gatewayapiv1.PathMatchType("")): {
+		string(gatewayapiv1.PathMatchExact),
+		string(gatewayapiv1.PathMatchPathPrefix),
+		string(gatewayapiv1.PathMatchRegularExpression),
+	},
+	reflect.TypeOf(gatewayapiv1.HeaderMatchType("")): {
+		string(gatewayapiv1.HeaderMatchExact),
+		string(gatewayapiv1.HeaderMatchRegularExpression),
+	},
+	reflect.TypeOf(OAuth2TokenValidation("")): {
+		string(OAuth2TokenValidationJWT),
+		string(OAuth2TokenValidationIntrospection),
+	},
+	reflect.TypeOf(ScopesClaim("")): {
+		string(ScopesClaimScope),
+		string(ScopesClaimScp),
+		string(ScopesClaimRoles),
+	},
+	reflect.TypeOf(kuadrantapiv1beta2.TimeUnit("")): {"second", "minute", "hour", "day"},
+	reflect.TypeOf(kuadrantapiv1beta2.WhenConditionOperator("")): {
+		string(kuadrantapiv1beta2.EqualOperator),
+		string(kuadrantapiv1beta2.NotEqualOperator),
+		string(kuadrantapiv1beta2.StartsWithOperator),
+		string(kuadrantapiv1beta2.EndsWithOperator),
+		string(kuadrantapiv1beta2.IncludeOperator),
+		string(kuadrantapiv1beta2.ExcludeOperator),
+		string(kuadrantapiv1beta2.MatchesOperator),
+	},
+}
+
 type RouteObject struct {
 	Name       *string                        `json:"name,omitempty"`
 	Namespace  *string                        `json:"namespace,omitempty"`
@@ -18,30 +57,57 @@ type RouteObject struct {
 	Labels     map[string]string              `json:"labels,omitempty"`
 }
 
+// KuadrantOASRootExtension holds the route and the defaults of the path and operation-level kuadrant extensions
 type KuadrantOASRootExtension struct {
-	Route *RouteObject `json:"route,omitempty"`
+	Route         *RouteObject                  `json:"route,omitempty"`
+	Disable       *bool                         `json:"disable,omitempty"`
+	PathMatchType *gatewayapiv1.PathMatchType   `json:"pathMatchType,omitempty"`
+	BackendRefs   []gatewayapiv1.HTTPBackendRef `json:"backendRefs,omitempty"`
+	RateLimit     *KuadrantRateLimitExtension   `json:"rate_limit,omitempty"`
 }
 
+// NewKuadrantOASRootExtension returns the root kuadrant extension of the document.
+// Nil when the root kuadrant extension is not present.
 func NewKuadrantOASRootExtension(doc *openapi3.T) (*KuadrantOASRootExtension, error) {
-	type KuadrantOASRootObject struct {
-		// Kuadrant extension
-		Kuadrant *KuadrantOASRootExtension `json:"x-kuadrant,omitempty"`
+	// only the extension is marshaled, marshaling the whole document is expensive for large documents
+	extension, ok := doc.Extensions[KuadrantExtensionKey]
+	if !ok || extension == nil {
+		return nil, nil
 	}
 
-	data, err := doc.MarshalJSON()
+	data, err := json.Marshal(extension)
 	if err != nil {
 		return nil, err
 	}
 
-	var x KuadrantOASRootObject
-	if err := json.Unmarshal(data, &x); err != nil {
+	var kuadrantExtension KuadrantOASRootExtension
+	if err := json.Unmarshal(data, &kuadrantExtension); err != nil {
 		return nil, err
 	}
 
-	return x.Kuadrant, nil
+	return &kuadrantExtension, nil
+}
+
+// KuadrantOASRootExtensionFromOAS returns the root kuadrant extension of the document,
+// to be parsed once per document and passed to NewKuadrantOASEffectiveExtension.
+// Nil when the root kuadrant extension is not present.
+func KuadrantOASRootExtensionFromOAS(doc *openapi3.T) (*KuadrantOASRootExtension, error) {
+	rootExtension, err := NewKuadrantOASRootExtension(doc)
+	if err != nil {
+		return nil, NewError(
+			JSONPointer(KuadrantExtensionKey),
+			"invalid openapi root kuadrant extension: %v", err,
+		)
+	}
+
+	return rootExtension, nil
 }
 
 type KuadrantRateLimitExtension struct {
+	// Name of the limit. Operations with the same limit name share the limit, and its counters.
+	// Default: the operation name
+	Name string `json:"name,omitempty"`
+
 	When []kuadrantapiv1beta2.WhenCondition `json:"when,omitempty"`
 
 	Counters []kuadrantapiv1beta2.ContextSelector `json:"counters,omitempty"`
@@ -49,11 +115,35 @@ type KuadrantRateLimitExtension struct {
 	Rates []kuadrantapiv1beta2.Rate `json:"rates,omitempty"`
 }
 
+// KuadrantAuthSuccessResponseExtension is the response of authenticated and authorized requests
+type KuadrantAuthSuccessResponseExtension struct {
+	Headers         map[string]authorinoapi.SuccessResponseSpec `json:"headers,omitempty"`
+	DynamicMetadata map[string]authorinoapi.SuccessResponseSpec `json:"dynamicMetadata,omitempty"`
+}
+
+// KuadrantAuthResponseExtension is the response of the requests. The denial responses apply to every operation
+// of the AuthPolicy, they cannot be scoped by route selectors.
+type KuadrantAuthResponseExtension struct {
+	Unauthenticated *authorinoapi.DenyWithSpec            `json:"unauthenticated,omitempty"`
+	Unauthorized    *authorinoapi.DenyWithSpec            `json:"unauthorized,omitempty"`
+	Success         *KuadrantAuthSuccessResponseExtension `json:"success,omitempty"`
+}
+
+// KuadrantAuthExtension holds the AuthPolicy rules of the operation, besides the authentication
+// generated from the security requirements
+type KuadrantAuthExtension struct {
+	Metadata      map[string]authorinoapi.MetadataSpec      `json:"metadata,omitempty"`
+	Authorization map[string]authorinoapi.AuthorizationSpec `json:"authorization,omitempty"`
+	Response      *KuadrantAuthResponseExtension            `json:"response,omitempty"`
+	Callbacks     map[string]authorinoapi.CallbackSpec      `json:"callbacks,omitempty"`
+}
+
 type KuadrantOASPathExtension struct {
 	Disable       *bool                         `json:"disable,omitempty"`
 	PathMatchType *gatewayapiv1.PathMatchType   `json:"pathMatchType,omitempty"`
 	BackendRefs   []gatewayapiv1.HTTPBackendRef `json:"backendRefs,omitempty"`
 	RateLimit     *KuadrantRateLimitExtension   `json:"rate_limit,omitempty"`
+	Auth          *KuadrantAuthExtension        `json:"auth,omitempty"`
 }
 
 func (k *KuadrantOASPathExtension) IsDisabled() bool {
@@ -72,7 +162,12 @@ func NewKuadrantOASPathExtension(pathItem *openapi3.PathItem) (*KuadrantOASPathE
 		Kuadrant *KuadrantOASPathExtension `json:"x-kuadrant,omitempty"`
 	}
 
-	data, err := pathItem.MarshalJSON()
+	// path items loaded from an external reference keep the reference,
+	// which would be marshaled instead of the path item
+	resolvedPathItem := *pathItem
+	resolvedPathItem.Ref = ""
+
+	data, err := resolvedPathItem.MarshalJSON()
 	if err != nil {
 		return nil, err
 	}
@@ -109,3 +204,121 @@ func NewKuadrantOASOperationExtension(operation *openapi3.Operation) (*KuadrantO
 
 	return &kuadrantExtension, nil
 }
+
+// KuadrantOASParameterMatch is the match of a header or query parameter, overriding the match derived from the parameter schema
+type KuadrantOASParameterMatch struct {
+	// Valid values: Exact, RegularExpression. Default: Exact
+	Type  *gatewayapiv1.HeaderMatchType `json:"type,omitempty"`
+	Value string                        `json:"value"`
+}
+
+func (k *KuadrantOASParameterMatch) GetType() gatewayapiv1.HeaderMatchType {
+	// Set default
+	return ptr.Deref(k.Type, gatewayapiv1.HeaderMatchExact)
+}
+
+type KuadrantOASParameterExtension struct {
+	// Disable suppresses the match of the parameter
+	Disable *bool `json:"disable,omitempty"`
+	// Match forces the match of the parameter, required or not
+	Match *KuadrantOASParameterMatch `json:"match,omitempty"`
+}
+
+func (k *KuadrantOASParameterExtension) IsDisabled() bool {
+	// Set default
+	return ptr.Deref(k.Disable, false)
+}
+
+func NewKuadrantOASParameterExtension(parameter *openapi3.Parameter) (*KuadrantOASParameterExtension, error) {
+	type KuadrantOASParameterObject struct {
+		// Kuadrant extension
+		Kuadrant *KuadrantOASParameterExtension `json:"x-kuadrant,omitempty"`
+	}
+
+	data, err := parameter.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var x KuadrantOASParameterObject
+	if err := json.Unmarshal(data, &x); err != nil {
+		return nil, err
+	}
+
+	kuadrantExtension := ptr.Deref(x.Kuadrant, KuadrantOASParameterExtension{})
+
+	return &kuadrantExtension, nil
+}
+
+// OAuth2TokenValidation defines how the tokens of an oauth2 security scheme are validated
+type OAuth2TokenValidation string
+
+const (
+	// OAuth2TokenValidationJWT validates the tokens as JWTs signed by the issuer
+	OAuth2TokenValidationJWT OAuth2TokenValidation = "jwt"
+	// OAuth2TokenValidationIntrospection validates the tokens with the OAuth2 token introspection endpoint
+	OAuth2TokenValidationIntrospection OAuth2TokenValidation = "introspection"
+)
+
+// ScopesClaim is the token claim holding the scopes granted
+type ScopesClaim string
+
+const (
+	// ScopesClaimScope is the space-separated list of scopes of RFC 8693
+	ScopesClaimScope ScopesClaim = "scope"
+	// ScopesClaimScp is the list of scopes of some identity providers, either an array or a space-separated list
+	ScopesClaimScp ScopesClaim = "scp"
+	// ScopesClaimRoles is the array of roles
+	ScopesClaimRoles ScopesClaim = "roles"
+)
+
+// KuadrantOASIntrospection is the OAuth2 token introspection of the tokens of the security scheme
+type KuadrantOASIntrospection struct {
+	// URL of the token introspection endpoint. Default: the tokenUrl of the oauth2 flow, followed by /introspect
+	URL *string `json:"url,omitempty"`
+	// CredentialsRef is the name of the Secret holding the clientID and clientSecret
+	// used to call the token introspection endpoint
+	CredentialsRef string `json:"credentialsRef"`
+}
+
+type KuadrantOASSecuritySchemeExtension struct {
+	// IssuerURL of the JWT tokens. Default: derived from the oauth2 flow URLs
+	IssuerURL *string `json:"issuerUrl,omitempty"`
+	// Valid values: jwt, introspection. Default: jwt
+	TokenValidation *OAuth2TokenValidation `json:"tokenValidation,omitempty"`
+	// Introspection of the tokens, required by the introspection token validation
+	Introspection *KuadrantOASIntrospection `json:"introspection,omitempty"`
+	// Valid values: scope, scp, roles. Default: scope
+	ScopesClaim *ScopesClaim `json:"scopesClaim,omitempty"`
+}
+
+func (k *KuadrantOASSecuritySchemeExtension) GetTokenValidation() OAuth2TokenValidation {
+	// Set default
+	return ptr.Deref(k.TokenValidation, OAuth2TokenValidationJWT)
+}
+
+func (k *KuadrantOASSecuritySchemeExtension) GetScopesClaim() ScopesClaim {
+	// Set default
+	return ptr.Deref(k.ScopesClaim, ScopesClaimScope)
+}
+
+func NewKuadrantOASSecuritySchemeExtension(secScheme *openapi3.SecurityScheme) (*KuadrantOASSecuritySchemeExtension, error) {
+	type KuadrantOASSecuritySchemeObject struct {
+		// Kuadrant extension
+		Kuadrant *KuadrantOASSecuritySchemeExtension `json:"x-kuadrant,omitempty"`
+	}
+
+	data, err := secScheme.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var x KuadrantOASSecuritySchemeObject
+	if err := json.Unmarshal(data, &x); err != nil {
+		return nil, err
+	}
+
+	kuadrantExtension := ptr.Deref(x.Kuadrant, KuadrantOASSecuritySchemeExtension{})
+
+	return &kuadrantExtension, nil
+}
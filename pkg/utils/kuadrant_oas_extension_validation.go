@@ -0,0 +1,262 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// ValidateKuadrantOASExtensions strictly checks every kuadrant extension of the OpenAPI document
+// against the extension types.
+// Unknown fields, values of the wrong type and invalid enum values are reported as errors.
+func ValidateKuadrantOASExtensions(doc *openapi3.T) Problems {
+	var problems Problems
+
+	if rootExtension, ok := doc.Extensions[KuadrantExtensionKey]; ok {
+		problems.Add(validateJSONValue(rootExtension, reflect.TypeOf(KuadrantOASRootExtension{}), []string{KuadrantExtensionKey})...)
+	} else {
+		problems.Add(NewWarning(JSONPointer(), "openapi root kuadrant extension not found, generated resources have neither name nor parentRefs"))
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		pathItem := doc.Paths[path]
+		if pathExtension, ok := pathItem.Extensions[KuadrantExtensionKey]; ok {
+			problems.Add(validateJSONValue(pathExtension, reflect.TypeOf(KuadrantOASPathExtension{}), []string{"paths", path, KuadrantExtensionKey})...)
+		}
+		problems.Add(validateParameterExtensions(pathItem.Parameters, []string{"paths", path, "parameters"})...)
+	}
+
+	for _, oasOperation := range OperationsFromOAS(doc, nil) {
+		if operationExtension, ok := oasOperation.Operation.Extensions[KuadrantExtensionKey]; ok {
+			tokens := []string{"paths", oasOperation.Path, strings.ToLower(oasOperation.Verb), KuadrantExtensionKey}
+			problems.Add(validateJSONValue(operationExtension, reflect.TypeOf(KuadrantOASOperationExtension{}), tokens)...)
+		}
+		tokens := []string{"paths", oasOperation.Path, strings.ToLower(oasOperation.Verb), "parameters"}
+		problems.Add(validateParameterExtensions(oasOperation.Operation.Parameters, tokens)...)
+	}
+
+	if doc.Components != nil {
+		for _, name := range SortedKeys(doc.Components.Parameters) {
+			parameterRef := doc.Components.Parameters[name]
+			if parameterRef.Ref != "" || parameterRef.Value == nil {
+				continue
+			}
+			if parameterExtension, ok := parameterRef.Value.Extensions[KuadrantExtensionKey]; ok {
+				tokens := []string{"components", "parameters", name, KuadrantExtensionKey}
+				problems.Add(validateJSONValue(parameterExtension, reflect.TypeOf(KuadrantOASParameterExtension{}), tokens)...)
+			}
+		}
+
+		for _, name := range SortedKeys(doc.Components.SecuritySchemes) {
+			secSchemeRef := doc.Components.SecuritySchemes[name]
+			if secSchemeRef.Ref != "" || secSchemeRef.Value == nil {
+				continue
+			}
+			if secSchemeExtension, ok := secSchemeRef.Value.Extensions[KuadrantExtensionKey]; ok {
+				tokens := []string{"components", "securitySchemes", name, KuadrantExtensionKey}
+				problems.Add(validateJSONValue(secSchemeExtension, reflect.TypeOf(KuadrantOASSecuritySchemeExtension{}), tokens)...)
+			}
+		}
+	}
+
+	return problems
+}
+
+// validateParameterExtensions checks the kuadrant extensions of the parameters defined inline.
+// Referenced parameters are checked where they are defined.
+func validateParameterExtensions(parameters openapi3.Parameters, tokens []string) Problems {
+	var problems Problems
+
+	for idx, parameterRef := range parameters {
+		if parameterRef.Ref != "" || parameterRef.Value == nil {
+			continue
+		}
+		if parameterExtension, ok := parameterRef.Value.Extensions[KuadrantExtensionKey]; ok {
+			parameterTokens := append(append([]string{}, tokens...), strconv.Itoa(idx), KuadrantExtensionKey)
+			problems.Add(validateJSONValue(parameterExtension, reflect.TypeOf(KuadrantOASParameterExtension{}), parameterTokens)...)
+		}
+	}
+
+	return problems
+}
+
+// validateJSONValue checks a generic JSON value (as decoded into interface{}) against the Go type
+// the value is unmarshalled to. Reference tokens locate the value in the OpenAPI document.
+func validateJSONValue(value any, t reflect.Type, tokens []string) Problems {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if value == nil {
+		return nil
+	}
+
+	pointer := JSONPointer(tokens...)
+
+	// Types with custom unmarshalling are only checked for decoding errors
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		if err := decodeJSONValue(value, t); err != nil {
+			return Problems{NewError(pointer, "invalid value: %v", err)}
+		}
+		return nil
+	}
+
+	if enumValues, ok := KuadrantOASExtensionEnums[t]; ok {
+		str, isString := value.(string)
+		if !isString {
+			return Problems{NewError(pointer, "invalid value %s: expected string", jsonValueString(value))}
+		}
+		for _, enumValue := range enumValues {
+			if str == enumValue {
+				return nil
+			}
+		}
+		return Problems{NewError(pointer, "invalid value %q: valid values: %v", str, enumValues)}
+	}
+
+	var problems Problems
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return Problems{NewError(pointer, "invalid value %s: expected object", jsonValueString(value))}
+		}
+
+		fields := map[string]reflect.Type{}
+		for _, field := range jsonFieldsOf(t) {
+			fields[field.name] = field.typ
+		}
+
+		for _, key := range SortedKeys(obj) {
+			fieldType, ok := fields[key]
+			if !ok {
+				problems.Add(NewError(pointer, "unknown field %q", key))
+				continue
+			}
+			problems.Add(validateJSONValue(obj[key], fieldType, append(tokens, key))...)
+		}
+	case reflect.Map:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return Problems{NewError(pointer, "invalid value %s: expected object", jsonValueString(value))}
+		}
+
+		for _, key := range SortedKeys(obj) {
+			problems.Add(validateJSONValue(obj[key], t.Elem(), append(tokens, key))...)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]any)
+		if !ok {
+			return Problems{NewError(pointer, "invalid value %s: expected array", jsonValueString(value))}
+		}
+
+		for idx, item := range items {
+			problems.Add(validateJSONValue(item, t.Elem(), append(tokens, strconv.Itoa(idx)))...)
+		}
+	default:
+		if err := decodeJSONValue(value, t); err != nil {
+			return Problems{NewError(pointer, "invalid value %s: expected %s", jsonValueString(value), jsonTypeName(t))}
+		}
+	}
+
+	return problems
+}
+
+func decodeJSONValue(value any, t reflect.Type) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, reflect.New(t).Interface())
+}
+
+// jsonField is a field of a Go struct as seen by encoding/json
+type jsonField struct {
+	name      string
+	typ       reflect.Type
+	omitEmpty bool
+}
+
+// jsonFieldsOf returns the fields encoding/json reads from a JSON object for the struct type.
+// Fields of embedded structs without JSON name are promoted.
+func jsonFieldsOf(t reflect.Type) []jsonField {
+	fields := make([]jsonField, 0, t.NumField())
+
+	for idx := 0; idx < t.NumField(); idx++ {
+		structField := t.Field(idx)
+		tag := structField.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, tagOptions, _ := strings.Cut(tag, ",")
+
+		if structField.Anonymous && name == "" {
+			embeddedType := structField.Type
+			if embeddedType.Kind() == reflect.Pointer {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				fields = append(fields, jsonFieldsOf(embeddedType)...)
+				continue
+			}
+		}
+
+		if !structField.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = structField.Name
+		}
+
+		fields = append(fields, jsonField{
+			name:      name,
+			typ:       structField.Type,
+			omitEmpty: strings.Contains(tagOptions, "omitempty"),
+		})
+	}
+
+	return fields
+}
+
+// jsonValueString returns the value in JSON notation, i.e. strings are quoted
+func jsonValueString(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(data)
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return t.Kind().String()
+	}
+}
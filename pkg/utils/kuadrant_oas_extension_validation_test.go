@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ValidateKuadrantOASExtensions", func() {
+	load := func(data string) *openapi3.T {
+		doc, err := openapi3.NewLoader().LoadFromData([]byte(data))
+		Expect(err).ToNot(HaveOccurred())
+		return doc
+	}
+
+	It("valid extensions", func() {
+		doc := load(`
+openapi: "3.0.3"
+info:
+  title: "Pet Store API"
+  version: "1.0.0"
+x-kuadrant:
+  route:
+    name: petstore
+    hostnames:
+      - example.com
+    parentRefs:
+      - name: gw
+paths:
+  /cat:
+    x-kuadrant:
+      pathMatchType: Exact
+      backendRefs:
+        - name: petstore
+          port: 80
+    get:
+      x-kuadrant:
+        rate_limit:
+          rates:
+            - limit: 1
+              duration: 10
+              unit: second
+          counters:
+            - auth.identity.username
+      responses:
+        405:
+          description: "invalid input"
+`)
+		Expect(ValidateKuadrantOASExtensions(doc)).To(BeEmpty())
+	})
+
+	It("missing root extension is a warning", func() {
+		doc := load(`
+openapi: "3.0.3"
+info:
+  title: "Pet Store API"
+  version: "1.0.0"
+paths: {}
+`)
+		problems := ValidateKuadrantOASExtensions(doc)
+		Expect(problems).To(HaveLen(1))
+		Expect(problems[0].Pointer).To(Equal("#"))
+		Expect(problems[0].Severity).To(Equal(SeverityWarning))
+	})
+
+	It("invalid extensions", func() {
+		doc := load(`
+openapi: "3.0.3"
+info:
+  title: "Pet Store API"
+  version: "1.0.0"
+x-kuadrant:
+  route:
+    name: petstore
+    hostname: example.com
+paths:
+  /cat:
+    x-kuadrant:
+      pathMatchType: Prefix
+      disable: "yes"
+    get:
+      x-kuadrant:
+        rate_limit:
+          rates:
+            - limit: 1
+              duration: 10
+              unit: seconds
+        auth:
+          authorization:
+            admins:
+              patternMatching:
+                pattern: []
+      parameters:
+        - name: X-Pet-Kind
+          in: header
+          schema:
+            type: string
+          x-kuadrant:
+            match:
+              type: Prefix
+              value: dog
+        - $ref: "#/components/parameters/kind"
+      responses:
+        405:
+          description: "invalid input"
+components:
+  parameters:
+    kind:
+      name: kind
+      in: query
+      schema:
+        type: string
+      x-kuadrant:
+        disable: 1
+  securitySchemes:
+    petstore_oauth2:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://example.com/token
+          scopes: {}
+      x-kuadrant:
+        tokenValidation: opaque
+`)
+		Expect(ValidateKuadrantOASExtensions(doc)).To(ConsistOf(
+			NewError("#/x-kuadrant/route", `unknown field "hostname"`),
+			NewError("#/paths/~1cat/x-kuadrant/pathMatchType", `invalid value "Prefix": valid values: [Exact PathPrefix RegularExpression]`),
+			NewError("#/paths/~1cat/x-kuadrant/disable", `invalid value "yes": expected boolean`),
+			NewError("#/paths/~1cat/get/x-kuadrant/rate_limit/rates/0/unit", `invalid value "seconds": valid values: [second minute hour day]`),
+			NewError("#/paths/~1cat/get/x-kuadrant/auth/authorization/admins/patternMatching", `unknown field "pattern"`),
+			NewError("#/paths/~1cat/get/parameters/0/x-kuadrant/match/type", `invalid value "Prefix": valid values: [Exact RegularExpression]`),
+			NewError("#/components/parameters/kind/x-kuadrant/disable", `invalid value 1: expected boolean`),
+			NewError("#/components/securitySchemes/petstore_oauth2/x-kuadrant/tokenValidation", `invalid value "opaque": valid values: [jwt introspection]`),
+		))
+	})
+})
+
+var _ = Describe("SourceMap", func() {
+	data := []byte(`openapi: "3.0.3"
+paths:
+  /cat:
+    get:
+      x-kuadrant:
+        rate_limit:
+          rates:
+            - limit: 1
+              unit: second
+`)
+
+	DescribeTable("Line",
+		func(pointer string, expected int) {
+			sourceMap, err := NewSourceMap(data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sourceMap.Line(pointer)).To(Equal(expected))
+		},
+		Entry("document root", "#", 1),
+		Entry("escaped path", "#/paths/~1cat/get", 4),
+		Entry("sequence item", "#/paths/~1cat/get/x-kuadrant/rate_limit/rates/0/unit", 9),
+		Entry("closest existing parent", "#/paths/~1cat/get/x-kuadrant/route_selectors", 5),
+	)
+})
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ConfigMapScheme is the URL scheme of the ConfigMap keys: configmap://NAMESPACE/NAME/KEY
+	ConfigMapScheme = "configmap"
+	// SecretScheme is the URL scheme of the Secret keys: secret://NAMESPACE/NAME/KEY
+	SecretScheme = "secret"
+)
+
+// KubernetesClientFunc returns the client reading the Kubernetes objects
+type KubernetesClientFunc func() (client.Reader, error)
+
+// ConfigMapResourceReader reads the ConfigMap keys located by configmap://NAMESPACE/NAME/KEY URLs
+func ConfigMapResourceReader(ctx context.Context, getClient KubernetesClientFunc) ResourceReader {
+	return func(location *url.URL) ([]byte, error) {
+		key, dataKey, err := kubernetesObjectKey(location)
+		if err != nil {
+			return nil, err
+		}
+
+		k8sClient, err := getClient()
+		if err != nil {
+			return nil, err
+		}
+
+		configMap := &corev1.ConfigMap{}
+		if err := k8sClient.Get(ctx, key, configMap); err != nil {
+			return nil, err
+		}
+
+		if data, ok := configMap.Data[dataKey]; ok {
+			return []byte(data), nil
+		}
+
+		if data, ok := configMap.BinaryData[dataKey]; ok {
+			return data, nil
+		}
+
+		return nil, fmt.Errorf("key %q not found in ConfigMap %s", dataKey, key)
+	}
+}
+
+// SecretResourceReader reads the Secret keys located by secret://NAMESPACE/NAME/KEY URLs
+func SecretResourceReader(ctx context.Context, getClient KubernetesClientFunc) ResourceReader {
+	return func(location *url.URL) ([]byte, error) {
+		key, dataKey, err := kubernetesObjectKey(location)
+		if err != nil {
+			return nil, err
+		}
+
+		k8sClient, err := getClient()
+		if err != nil {
+			return nil, err
+		}
+
+		secret := &corev1.Secret{}
+		if err := k8sClient.Get(ctx, key, secret); err != nil {
+			return nil, err
+		}
+
+		data, ok := secret.Data[dataKey]
+		if !ok {
+			return nil, fmt.Errorf("key %q not found in Secret %s", dataKey, key)
+		}
+
+		return data, nil
+	}
+}
+
+// kubernetesObjectKey returns the key of the object and the data key located by SCHEME://NAMESPACE/NAME/KEY URLs
+func kubernetesObjectKey(location *url.URL) (client.ObjectKey, string, error) {
+	segments := strings.Split(strings.TrimPrefix(location.Path, "/"), "/")
+	if location.Host == "" || len(segments) != 2 || segments[0] == "" || segments[1] == "" {
+		return client.ObjectKey{}, "", fmt.Errorf("invalid %s URL %q, expected %s://NAMESPACE/NAME/KEY",
+			location.Scheme, location.String(), location.Scheme)
+	}
+
+	return client.ObjectKey{Namespace: location.Host, Name: segments[0]}, segments[1], nil
+}
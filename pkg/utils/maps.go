@@ -1,5 +1,7 @@
 package utils
 
+import "sort"
+
 func MergeMaps[K comparable, V any](MyMap1 map[K]V, MyMap2 map[K]V) map[K]V {
 	merged := make(map[K]V)
 	for key, val := range MyMap1 {
@@ -10,3 +12,14 @@ func MergeMaps[K comparable, V any](MyMap1 map[K]V, MyMap2 map[K]V) map[K]V {
 	}
 	return merged
 }
+
+// SortedKeys returns the keys of the map in order, for deterministic output
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// BundleOpenAPIDocument replaces the external references of the loaded OpenAPI document by the values they resolve to.
+// References to the document itself are kept, references found within the values of external references
+// are replaced as well, as they are relative to another document.
+// Circular external references cannot be replaced and are reported as an error.
+func BundleOpenAPIDocument(doc *openapi3.T) error {
+	b := &bundler{visited: map[bundleVisit]bool{}, inlining: map[uintptr]bool{}}
+	return b.walk(reflect.ValueOf(doc), false)
+}
+
+type bundleVisit struct {
+	ptr      uintptr
+	external bool
+}
+
+type bundler struct {
+	// visited pointers, by the kind of document the values are read from
+	visited map[bundleVisit]bool
+	// values of the external references being inlined, to detect cycles
+	inlining map[uintptr]bool
+}
+
+// walk visits the value, read from an external document when external is true
+func (b *bundler) walk(v reflect.Value, external bool) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		visit := bundleVisit{v.Pointer(), external}
+		if b.visited[visit] {
+			return nil
+		}
+		b.visited[visit] = true
+		return b.walk(v.Elem(), external)
+	case reflect.Struct:
+		return b.walkStruct(v, external)
+	case reflect.Slice, reflect.Array:
+		for idx := 0; idx < v.Len(); idx++ {
+			if err := b.walk(v.Index(idx), external); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := b.walk(iter.Value(), external); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (b *bundler) walkStruct(v reflect.Value, external bool) error {
+	refField := v.FieldByName("Ref")
+	valueField := v.FieldByName("Value")
+
+	if refField.IsValid() && refField.Kind() == reflect.String && refField.String() != "" {
+		ref := refField.String()
+		if external || !strings.HasPrefix(ref, "#") {
+			// replaced by the value
+			if refField.CanSet() {
+				refField.SetString("")
+			}
+			external = true
+
+			if valueField.IsValid() && valueField.Kind() == reflect.Pointer && !valueField.IsNil() {
+				ptr := valueField.Pointer()
+				if b.inlining[ptr] {
+					return fmt.Errorf("circular external reference %q cannot be bundled", ref)
+				}
+				b.inlining[ptr] = true
+				defer delete(b.inlining, ptr)
+				// visited again: the value may be inlined several times
+				delete(b.visited, bundleVisit{ptr, true})
+			}
+		}
+	}
+
+	for idx := 0; idx < v.NumField(); idx++ {
+		if !v.Type().Field(idx).IsExported() {
+			continue
+		}
+		if err := b.walk(v.Field(idx), external); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// OperationOrder defines the order in which the paths and operations of an OpenAPI document are processed.
+// Paths are sorted first and then, within a path, operations are sorted by method.
+type OperationOrder string
+
+const (
+	// OperationOrderAlphabetical sorts paths and methods alphabetically
+	OperationOrderAlphabetical OperationOrder = "alphabetical"
+	// OperationOrderSpec keeps paths and methods in the order they are written in the OpenAPI document
+	OperationOrderSpec OperationOrder = "spec"
+)
+
+// OperationOrders lists the supported operation orders
+var OperationOrders = []OperationOrder{OperationOrderAlphabetical, OperationOrderSpec}
+
+func (o OperationOrder) Validate() error {
+	for _, order := range OperationOrders {
+		if o == order {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown operation order %q, valid values: %v", o, OperationOrders)
+}
+
+// SpecOrder records the position of paths and operations as written in the OpenAPI document.
+// Go maps used by the OpenAPI object model do not keep that information.
+type SpecOrder struct {
+	paths      map[string]int
+	operations map[string]map[string]int
+}
+
+// NewSpecOrder reads the position of paths and operations from the raw OpenAPI document (JSON or YAML).
+func NewSpecOrder(data []byte) (*SpecOrder, error) {
+	specOrder := &SpecOrder{
+		paths:      map[string]int{},
+		operations: map[string]map[string]int{},
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	if len(root.Content) == 0 {
+		return specOrder, nil
+	}
+
+	pathsNode := mappingValue(root.Content[0], "paths")
+	if pathsNode == nil || pathsNode.Kind != yaml.MappingNode {
+		return specOrder, nil
+	}
+
+	for idx := 0; idx+1 < len(pathsNode.Content); idx += 2 {
+		path := pathsNode.Content[idx].Value
+		specOrder.paths[path] = idx / 2
+		specOrder.operations[path] = map[string]int{}
+
+		pathItemNode := pathsNode.Content[idx+1]
+		if pathItemNode.Kind != yaml.MappingNode {
+			continue
+		}
+
+		for opIdx := 0; opIdx+1 < len(pathItemNode.Content); opIdx += 2 {
+			verb := strings.ToUpper(pathItemNode.Content[opIdx].Value)
+			specOrder.operations[path][verb] = opIdx / 2
+		}
+	}
+
+	return specOrder, nil
+}
+
+// mappingValue returns the value node of the given key in a YAML mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for idx := 0; idx+1 < len(node.Content); idx += 2 {
+		if node.Content[idx].Value == key {
+			return node.Content[idx+1]
+		}
+	}
+
+	return nil
+}
+
+// OASOperation is one operation of the OpenAPI document together with the path it belongs to
+type OASOperation struct {
+	Path      string
+	PathItem  *openapi3.PathItem
+	Verb      string
+	Operation *openapi3.Operation
+}
+
+// OperationsFromOAS returns every operation of the OpenAPI document in a stable order.
+// Paths are sorted first and then, within a path, operations are sorted by method,
+// both according to the operation order of the options.
+func OperationsFromOAS(doc *openapi3.T, opts *GenerateOptions) []OASOperation {
+	order := opts.GetOperationOrder()
+	specOrder := opts.GetSpecOrder()
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sortKeys(paths, order, specOrder.paths)
+
+	operations := make([]OASOperation, 0)
+	for _, path := range paths {
+		pathItem := doc.Paths[path]
+
+		pathOperations := pathItem.Operations()
+		verbs := make([]string, 0, len(pathOperations))
+		for verb := range pathOperations {
+			verbs = append(verbs, verb)
+		}
+		sortKeys(verbs, order, specOrder.operations[path])
+
+		for _, verb := range verbs {
+			operations = append(operations, OASOperation{
+				Path:      path,
+				PathItem:  pathItem,
+				Verb:      verb,
+				Operation: pathOperations[verb],
+			})
+		}
+	}
+
+	return operations
+}
+
+// sortKeys sorts alphabetically or by spec position.
+// Keys without known spec position go last, sorted alphabetically.
+func sortKeys(keys []string, order OperationOrder, positions map[string]int) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		if order == OperationOrderSpec {
+			posI, okI := positions[keys[i]]
+			posJ, okJ := positions[keys[j]]
+			switch {
+			case okI && okJ:
+				return posI < posJ
+			case okI != okJ:
+				return okI
+			}
+		}
+
+		return keys[i] < keys[j]
+	})
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OperationsFromOAS", func() {
+	var (
+		oasData = []byte(`
+openapi: "3.0.3"
+info:
+  title: "Pet Store API"
+  version: "1.0.0"
+paths:
+  /dog:
+    post:
+      operationId: "postDog"
+      responses:
+        405:
+          description: "invalid input"
+    get:
+      operationId: "getDog"
+      responses:
+        405:
+          description: "invalid input"
+  /cat:
+    put:
+      operationId: "putCat"
+      responses:
+        405:
+          description: "invalid input"
+    delete:
+      operationId: "deleteCat"
+      responses:
+        405:
+          description: "invalid input"
+`)
+		doc *openapi3.T
+	)
+
+	BeforeEach(func() {
+		var err error
+		doc, err = openapi3.NewLoader().LoadFromData(oasData)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	operationIDs := func(operations []OASOperation) []string {
+		ids := make([]string, 0, len(operations))
+		for _, operation := range operations {
+			ids = append(ids, operation.Operation.OperationID)
+		}
+		return ids
+	}
+
+	It("sorts alphabetically by default", func() {
+		Expect(operationIDs(OperationsFromOAS(doc, nil))).To(HaveExactElements(
+			"deleteCat", "putCat", "getDog", "postDog",
+		))
+	})
+
+	It("sorts as written in the spec", func() {
+		specOrder, err := NewSpecOrder(oasData)
+		Expect(err).ToNot(HaveOccurred())
+		opts := &GenerateOptions{OperationOrder: OperationOrderSpec, SpecOrder: specOrder}
+		Expect(operationIDs(OperationsFromOAS(doc, opts))).To(HaveExactElements(
+			"postDog", "getDog", "putCat", "deleteCat",
+		))
+	})
+
+	It("falls back to alphabetical when the spec order is unknown", func() {
+		opts := &GenerateOptions{OperationOrder: OperationOrderSpec}
+		Expect(operationIDs(OperationsFromOAS(doc, opts))).To(HaveExactElements(
+			"deleteCat", "putCat", "getDog", "postDog",
+		))
+	})
+
+	It("is stable across runs", func() {
+		expected := operationIDs(OperationsFromOAS(doc, nil))
+		for i := 0; i < 50; i++ {
+			Expect(operationIDs(OperationsFromOAS(doc, nil))).To(Equal(expected))
+		}
+	})
+})
+
+var _ = DescribeTable("OperationOrder validation",
+	func(order OperationOrder, valid bool) {
+		if valid {
+			Expect(order.Validate()).To(Succeed())
+		} else {
+			Expect(order.Validate()).ToNot(Succeed())
+		}
+	},
+	Entry("alphabetical", OperationOrderAlphabetical, true),
+	Entry("spec", OperationOrderSpec, true),
+	Entry("unknown", OperationOrder("random"), false),
+)
@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// PathTemplateMatch defines how templated paths, like /pets/{petId}, are matched.
+type PathTemplateMatch string
+
+const (
+	// PathTemplateMatchRegex matches templated paths with a regular expression derived from the path parameter schemas
+	PathTemplateMatchRegex PathTemplateMatch = "regex"
+	// PathTemplateMatchPrefix matches templated paths by the prefix before the first templated segment,
+	// for implementations without regular expression support
+	PathTemplateMatchPrefix PathTemplateMatch = "prefix"
+)
+
+// PathTemplateMatches lists the supported path template matches
+var PathTemplateMatches = []PathTemplateMatch{PathTemplateMatchRegex, PathTemplateMatchPrefix}
+
+func (p PathTemplateMatch) Validate() error {
+	for _, match := range PathTemplateMatches {
+		if p == match {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown path template match %q, valid values: %v", p, PathTemplateMatches)
+}
+
+var (
+	// PathTemplateRegexp matches the templated segments of a path, like {petId}
+	PathTemplateRegexp = regexp.MustCompile(`{([^{}]+)}`)
+
+	uuidRegex = `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`
+
+	anySegmentRegex = `[^/]+`
+)
+
+// pathMatchFromOAS returns the path match of the operation.
+// Paths without templated segments are matched with the given path match type.
+// Templated paths are matched with a regular expression derived from the path parameters schemas
+// or, when the options prefer so, with the prefix before the first templated segment.
+// Templated paths with the PathPrefix match type set in the kuadrant extensions are left untouched.
+func pathMatchFromOAS(basePath, path string, parameters []locatedParameter, pathMatchType gatewayapiv1.PathMatchType, opts *GenerateOptions) gatewayapiv1.HTTPPathMatch {
+	// remove the last slash of the Base Path
+	sanitizedBasePath := LastSlashRegexp.ReplaceAllString(basePath, "")
+
+	//  According OAS 3.0: path MUST begin with a slash
+	matchPath := fmt.Sprintf("%s%s", sanitizedBasePath, path)
+
+	if !PathTemplateRegexp.MatchString(path) || pathMatchType == gatewayapiv1.PathMatchPathPrefix {
+		return gatewayapiv1.HTTPPathMatch{
+			Type:  &pathMatchType,
+			Value: &matchPath,
+		}
+	}
+
+	if opts.GetPathTemplateMatch() == PathTemplateMatchPrefix {
+		prefix := path[:strings.LastIndex(path[:PathTemplateRegexp.FindStringIndex(path)[0]], "/")]
+		matchPath = fmt.Sprintf("%s%s", sanitizedBasePath, prefix)
+		if matchPath == "" {
+			matchPath = "/"
+		}
+
+		return gatewayapiv1.HTTPPathMatch{
+			Type:  &[]gatewayapiv1.PathMatchType{gatewayapiv1.PathMatchPathPrefix}[0],
+			Value: &matchPath,
+		}
+	}
+
+	regex := "^" + regexp.QuoteMeta(sanitizedBasePath) + pathTemplateRegex(path, parameters, opts) + "$"
+
+	return gatewayapiv1.HTTPPathMatch{
+		Type:  &[]gatewayapiv1.PathMatchType{gatewayapiv1.PathMatchRegularExpression}[0],
+		Value: &regex,
+	}
+}
+
+// pathTemplateRegex returns the regular expression matching the templated path.
+// Literal parts are quoted, templated segments are replaced by the regular expression of their path parameter.
+func pathTemplateRegex(path string, parameters []locatedParameter, opts *GenerateOptions) string {
+	var regex strings.Builder
+
+	last := 0
+	for _, loc := range PathTemplateRegexp.FindAllStringSubmatchIndex(path, -1) {
+		regex.WriteString(regexp.QuoteMeta(path[last:loc[0]]))
+		regex.WriteString(pathParameterRegex(findLocatedParameter(parameters, openapi3.ParameterInPath, path[loc[2]:loc[3]]), opts))
+		last = loc[1]
+	}
+	regex.WriteString(regexp.QuoteMeta(path[last:]))
+
+	return regex.String()
+}
+
+// pathParameterRegex returns the regular expression matching the values of the path parameter, from its schema:
+// the enum values, the pattern, or the values of the type and format. Any segment otherwise.
+func pathParameterRegex(parameter *locatedParameter, opts *GenerateOptions) string {
+	if parameter == nil || parameter.Value.Schema == nil || parameter.Value.Schema.Value == nil {
+		return anySegmentRegex
+	}
+
+	schema := parameter.Value.Schema.Value
+
+	if len(schema.Enum) > 0 {
+		values := make([]string, 0, len(schema.Enum))
+		for _, value := range schema.Enum {
+			values = append(values, regexp.QuoteMeta(fmt.Sprintf("%v", value)))
+		}
+		return "(?:" + strings.Join(values, "|") + ")"
+	}
+
+	if schema.Pattern != "" {
+		regex, err := segmentPatternRegex(schema.Pattern)
+		if err != nil {
+			opts.Warn(NewWarning(
+				parameter.pointer("schema", "pattern"),
+				"path parameter %q pattern %q not matched, any segment value is matched instead: %v", parameter.Value.Name, schema.Pattern, err,
+			))
+			return anySegmentRegex
+		}
+		return regex
+	}
+
+	switch schema.Type {
+	case openapi3.TypeInteger:
+		return `-?[0-9]+`
+	case openapi3.TypeNumber:
+		return `-?[0-9]+(?:\.[0-9]+)?`
+	case openapi3.TypeBoolean:
+		return `(?:true|false)`
+	case openapi3.TypeString:
+		if schema.Format == "uuid" {
+			return uuidRegex
+		}
+	}
+
+	return anySegmentRegex
+}
+
+// segmentPatternRegex returns the regular expression matching the path segments matched by the pattern
+func segmentPatternRegex(pattern string) (string, error) {
+	return embeddedPatternRegex(pattern, `[^/]*`)
+}
+
+// valuePatternRegex returns the regular expression fully matching the header or query parameter values matched by the pattern,
+// like the regular expressions of the route matches are evaluated
+func valuePatternRegex(pattern string) (string, error) {
+	regex, err := embeddedPatternRegex(pattern, `.*`)
+	if err != nil {
+		return "", err
+	}
+	return "^" + regex + "$", nil
+}
+
+// embeddedPatternRegex returns the pattern to be embedded in a regular expression: its leading '^' and trailing '$' anchors are removed,
+// and an unanchored pattern, matching the values containing a match, may be preceded or followed by any characters, as matched by anyChars.
+// Patterns with other anchors, like alternatives of anchored patterns, cannot be embedded.
+func embeddedPatternRegex(pattern, anyChars string) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+
+	anchoredStart, anchoredEnd := false, false
+	if re.Op == syntax.OpConcat && len(re.Sub) > 1 {
+		anchoredStart = isBeginAnchor(re.Sub[0]) && strings.HasPrefix(pattern, "^")
+		anchoredEnd = isEndAnchor(re.Sub[len(re.Sub)-1]) && strings.HasSuffix(pattern, "$")
+	}
+
+	embedded := pattern
+	if anchoredStart {
+		embedded = strings.TrimPrefix(embedded, "^")
+	}
+	if anchoredEnd {
+		embedded = strings.TrimSuffix(embedded, "$")
+	}
+
+	inner, err := syntax.Parse(embedded, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+	if hasAnchor(inner) {
+		return "", errors.New("anchors only supported at the start and the end of the pattern")
+	}
+
+	regex := "(?:" + embedded + ")"
+	if !anchoredStart {
+		regex = anyChars + regex
+	}
+	if !anchoredEnd {
+		regex += anyChars
+	}
+
+	return regex, nil
+}
+
+func isBeginAnchor(re *syntax.Regexp) bool {
+	return re.Op == syntax.OpBeginText || re.Op == syntax.OpBeginLine
+}
+
+func isEndAnchor(re *syntax.Regexp) bool {
+	return re.Op == syntax.OpEndText || re.Op == syntax.OpEndLine
+}
+
+func hasAnchor(re *syntax.Regexp) bool {
+	if isBeginAnchor(re) || isEndAnchor(re) {
+		return true
+	}
+
+	for _, sub := range re.Sub {
+		if hasAnchor(sub) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"regexp"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("Path templates", func() {
+	pathParameter := func(name string, schema *openapi3.Schema) *openapi3.ParameterRef {
+		return &openapi3.ParameterRef{Value: &openapi3.Parameter{
+			Name: name, In: openapi3.ParameterInPath, Required: true, Schema: schema.NewRef(),
+		}}
+	}
+
+	// located parameters defined at the path level
+	located := func(path string, parameters openapi3.Parameters) []locatedParameter {
+		return operationLocatedParameters(path, "GET", &openapi3.PathItem{Parameters: parameters}, &openapi3.Operation{})
+	}
+
+	DescribeTable("pathMatchFromOAS",
+		func(path string, parameters openapi3.Parameters, pathMatchType gatewayapiv1.PathMatchType, opts *GenerateOptions, expectedType gatewayapiv1.PathMatchType, expectedValue string) {
+			pathMatch := pathMatchFromOAS("/v1/", path, located(path, parameters), pathMatchType, opts)
+			Expect(*pathMatch.Type).To(Equal(expectedType))
+			Expect(*pathMatch.Value).To(Equal(expectedValue))
+		},
+		Entry("not templated", "/pets", nil, gatewayapiv1.PathMatchExact, nil,
+			gatewayapiv1.PathMatchExact, "/v1/pets"),
+		Entry("integer", "/pets/{petId}", openapi3.Parameters{pathParameter("petId", openapi3.NewIntegerSchema())}, gatewayapiv1.PathMatchExact, nil,
+			gatewayapiv1.PathMatchRegularExpression, `^/v1/pets/-?[0-9]+$`),
+		Entry("uuid", "/pets/{petId}", openapi3.Parameters{pathParameter("petId", openapi3.NewUUIDSchema())}, gatewayapiv1.PathMatchExact, nil,
+			gatewayapiv1.PathMatchRegularExpression, `^/v1/pets/[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`),
+		Entry("pattern", "/pets/{petId}", openapi3.Parameters{pathParameter("petId", openapi3.NewStringSchema().WithPattern(`^[a-z]{3}$`))}, gatewayapiv1.PathMatchExact, nil,
+			gatewayapiv1.PathMatchRegularExpression, `^/v1/pets/(?:[a-z]{3})$`),
+		Entry("unanchored pattern", "/pets/{petId}", openapi3.Parameters{pathParameter("petId", openapi3.NewStringSchema().WithPattern(`[0-9]`))}, gatewayapiv1.PathMatchExact, nil,
+			gatewayapiv1.PathMatchRegularExpression, `^/v1/pets/[^/]*(?:[0-9])[^/]*$`),
+		Entry("pattern anchored at the start", "/pets/{petId}", openapi3.Parameters{pathParameter("petId", openapi3.NewStringSchema().WithPattern(`^pet-`))}, gatewayapiv1.PathMatchExact, nil,
+			gatewayapiv1.PathMatchRegularExpression, `^/v1/pets/(?:pet-)[^/]*$`),
+		Entry("alternatives of anchored patterns", "/pets/{petId}", openapi3.Parameters{pathParameter("petId", openapi3.NewStringSchema().WithPattern(`^a$|^b$`))}, gatewayapiv1.PathMatchExact, nil,
+			gatewayapiv1.PathMatchRegularExpression, `^/v1/pets/[^/]+$`),
+		Entry("enum", "/files/{name}.{ext}", openapi3.Parameters{pathParameter("ext", openapi3.NewStringSchema().WithEnum("tar.gz", "zip"))}, gatewayapiv1.PathMatchExact, nil,
+			gatewayapiv1.PathMatchRegularExpression, `^/v1/files/[^/]+\.(?:tar\.gz|zip)$`),
+		Entry("parameter not declared", "/pets/{petId}", nil, gatewayapiv1.PathMatchExact, nil,
+			gatewayapiv1.PathMatchRegularExpression, `^/v1/pets/[^/]+$`),
+		Entry("prefix fallback", "/pets/{petId}/photos", nil, gatewayapiv1.PathMatchExact, &GenerateOptions{PathTemplateMatch: PathTemplateMatchPrefix},
+			gatewayapiv1.PathMatchPathPrefix, "/v1/pets"),
+		Entry("prefix fallback at the root", "/{petId}", nil, gatewayapiv1.PathMatchExact, &GenerateOptions{PathTemplateMatch: PathTemplateMatchPrefix},
+			gatewayapiv1.PathMatchPathPrefix, "/v1"),
+		Entry("path prefix set in the kuadrant extension", "/pets/{petId}", nil, gatewayapiv1.PathMatchPathPrefix, nil,
+			gatewayapiv1.PathMatchPathPrefix, "/v1/pets/{petId}"),
+	)
+
+	It("regular expression matches the templated path", func() {
+		parameters := openapi3.Parameters{
+			pathParameter("petId", openapi3.NewIntegerSchema()),
+			pathParameter("kind", openapi3.NewStringSchema().WithEnum("cat", "dog")),
+		}
+		pathMatch := pathMatchFromOAS("/", "/pets/{kind}/{petId}", located("/pets/{kind}/{petId}", parameters), gatewayapiv1.PathMatchExact, nil)
+		regex := regexp.MustCompile(*pathMatch.Value)
+
+		Expect(regex.MatchString("/pets/cat/42")).To(BeTrue())
+		Expect(regex.MatchString("/pets/bird/42")).To(BeFalse())
+		Expect(regex.MatchString("/pets/cat/felix")).To(BeFalse())
+		Expect(regex.MatchString("/pets/cat/42/photos")).To(BeFalse())
+	})
+
+	It("patterns match the segments containing a match", func() {
+		parameters := openapi3.Parameters{pathParameter("petId", openapi3.NewStringSchema().WithPattern(`[0-9]`))}
+		regex := regexp.MustCompile(*pathMatchFromOAS("/", "/pets/{petId}", located("/pets/{petId}", parameters), gatewayapiv1.PathMatchExact, nil).Value)
+
+		Expect(regex.MatchString("/pets/felix42")).To(BeTrue())
+		Expect(regex.MatchString("/pets/felix")).To(BeFalse())
+		Expect(regex.MatchString("/pets/felix/42")).To(BeFalse())
+	})
+
+	It("patterns with inner anchors are reported", func() {
+		var warnings Problems
+		opts := &GenerateOptions{WarningHandler: func(problem Problem) { warnings.Add(problem) }}
+		parameters := openapi3.Parameters{pathParameter("petId", openapi3.NewStringSchema().WithPattern(`^a$|^b$`))}
+
+		pathMatchFromOAS("/", "/pets/{petId}", located("/pets/{petId}", parameters), gatewayapiv1.PathMatchExact, opts)
+		Expect(warnings).To(ConsistOf(NewWarning("#/paths/~1pets~1{petId}/parameters/0/schema/pattern",
+			`path parameter "petId" pattern "^a$|^b$" not matched, any segment value is matched instead: anchors only supported at the start and the end of the pattern`)))
+	})
+
+	It("operation parameters override the path parameters", func() {
+		pathItem := &openapi3.PathItem{Parameters: openapi3.Parameters{
+			pathParameter("petId", openapi3.NewStringSchema()),
+			pathParameter("kind", openapi3.NewStringSchema()),
+		}}
+		op := &openapi3.Operation{Parameters: openapi3.Parameters{pathParameter("petId", openapi3.NewIntegerSchema())}}
+
+		parameters := operationLocatedParameters("/pets/{kind}/{petId}", "GET", pathItem, op)
+		Expect(parameters).To(HaveLen(2))
+		petID := findLocatedParameter(parameters, openapi3.ParameterInPath, "petId")
+		Expect(petID.Value.Schema.Value.Type).To(Equal(openapi3.TypeInteger))
+		Expect(petID.pointer()).To(Equal("#/paths/~1pets~1{kind}~1{petId}/get/parameters/0"))
+		Expect(findLocatedParameter(parameters, openapi3.ParameterInPath, "kind")).ToNot(BeNil())
+	})
+
+	It("unknown path template match rejected", func() {
+		Expect(PathTemplateMatch("glob").Validate()).To(MatchError(ContainSubstring(`unknown path template match "glob"`)))
+	})
+})
@@ -0,0 +1,376 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// ServerFromOAS returns the server of the OpenAPI document selected by the options, by index or by URL,
+// along with its index. Without selection, the first server is returned.
+// The index is -1 for the default server, when the document does not declare any server.
+func ServerFromOAS(doc *openapi3.T, opts *GenerateOptions) (*openapi3.Server, int, error) {
+	serverIndex, serverURL := opts.GetServerIndex(), opts.GetServerURL()
+
+	switch {
+	case serverIndex != nil && serverURL != "":
+		return nil, 0, fmt.Errorf("server index and server URL are mutually exclusive")
+	case serverIndex != nil:
+		if *serverIndex < 0 || *serverIndex >= len(doc.Servers) {
+			return nil, 0, NewError(JSONPointer("servers"), "server index %d out of range, %d servers found", *serverIndex, len(doc.Servers))
+		}
+		return doc.Servers[*serverIndex], *serverIndex, nil
+	case serverURL != "":
+		available := make([]string, 0, len(doc.Servers))
+		for idx, server := range doc.Servers {
+			if server.URL == serverURL {
+				return server, idx, nil
+			}
+			// server URL with the variables rendered
+			if rendered, err := RenderOpenAPIServerURLStrWithVariables(server, opts.GetServerVariables()); err == nil && rendered == serverURL {
+				return server, idx, nil
+			}
+			available = append(available, server.URL)
+		}
+		return nil, 0, NewError(JSONPointer("servers"), "server %q not found, available servers: %v", serverURL, available)
+	}
+
+	// From https://github.com/OAI/OpenAPI-Specification/blob/master/versions/3.0.3.md
+	//   If the servers property is not provided, or is an empty array, the default value would be a Server Object with a url value of /.
+	if len(doc.Servers) == 0 {
+		return &openapi3.Server{URL: "/", Variables: map[string]*openapi3.ServerVariable{}}, -1, nil
+	}
+
+	return doc.Servers[0], 0, nil
+}
+
+// BasePathFromOAS returns the path of the selected server URL, rendered with the variables of the options
+func BasePathFromOAS(doc *openapi3.T, opts *GenerateOptions) (string, error) {
+	server, serverIndex, err := ServerFromOAS(doc, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return serverBasePath(locatedServer{server, []string{"servers", strconv.Itoa(max(serverIndex, 0))}}, opts)
+}
+
+// OperationBasePathFromOAS returns the base path of the operation.
+// Servers defined at the operation level override the ones at the path level,
+// which override the servers of the document. Like for the document, the first server is read.
+func OperationBasePathFromOAS(doc *openapi3.T, oasOperation OASOperation, opts *GenerateOptions) (string, error) {
+	servers := operationServers(oasOperation)
+	if len(servers) == 0 {
+		return BasePathFromOAS(doc, opts)
+	}
+
+	return serverBasePath(servers[0], opts)
+}
+
+// locatedServer is a server along with the JSON pointer reference tokens locating it in the document
+type locatedServer struct {
+	server *openapi3.Server
+	tokens []string
+}
+
+func (l locatedServer) pointer(tokens ...string) string {
+	return JSONPointer(append(append([]string{}, l.tokens...), tokens...)...)
+}
+
+// operationServers returns the servers overriding the document servers for the operation, if any
+func operationServers(oasOperation OASOperation) []locatedServer {
+	var servers openapi3.Servers
+	var tokens []string
+
+	switch {
+	case oasOperation.Operation.Servers != nil && len(*oasOperation.Operation.Servers) > 0:
+		servers = *oasOperation.Operation.Servers
+		tokens = []string{"paths", oasOperation.Path, strings.ToLower(oasOperation.Verb), "servers"}
+	case len(oasOperation.PathItem.Servers) > 0:
+		servers = oasOperation.PathItem.Servers
+		tokens = []string{"paths", oasOperation.Path, "servers"}
+	}
+
+	located := make([]locatedServer, 0, len(servers))
+	for idx, server := range servers {
+		located = append(located, locatedServer{server, append(append([]string{}, tokens...), strconv.Itoa(idx))})
+	}
+
+	return located
+}
+
+// serversInUse returns the servers the base paths are read from: the selected server of the document
+// and the first server overriding the document servers for each operation
+func serversInUse(doc *openapi3.T, opts *GenerateOptions) []locatedServer {
+	var servers []locatedServer
+	if server, serverIndex, err := ServerFromOAS(doc, opts); err == nil {
+		servers = append(servers, locatedServer{server, []string{"servers", strconv.Itoa(max(serverIndex, 0))}})
+	}
+
+	for _, oasOperation := range OperationsFromOAS(doc, opts) {
+		if operationServers := operationServers(oasOperation); len(operationServers) > 0 {
+			servers = append(servers, operationServers[0])
+		}
+	}
+
+	return servers
+}
+
+// serverBasePath returns the path of the server URL, rendered with the variables of the options.
+// The variables are validated once per document, see ValidateServerVariables.
+func serverBasePath(server locatedServer, opts *GenerateOptions) (string, error) {
+	serverURLStr, err := RenderOpenAPIServerURLStrWithVariables(server.server, opts.GetServerVariables())
+	if err == nil {
+		var serverURL *url.URL
+		serverURL, err = url.Parse(serverURLStr)
+		if err == nil {
+			return serverURL.Path, nil
+		}
+	}
+
+	return "", NewError(server.pointer("url"), "invalid server url: %v", err)
+}
+
+// HostnamesFromOASServers returns the hosts of the server URLs.
+// When a server is selected by the options, only the selected server of the document is read,
+// along with the first server overriding the document servers for the operations.
+// Otherwise, every server is read, including the servers overriding the document servers
+// for the operations. Disabled operations are not taken into account.
+// Variables without value in the options are rendered with every value of their enum, when defined.
+// Relative server URLs do not provide any hostname. IP addresses are ignored.
+func HostnamesFromOASServers(doc *openapi3.T, opts *GenerateOptions) ([]gatewayapiv1.Hostname, error) {
+	serverSelected := opts.GetServerIndex() != nil || opts.GetServerURL() != ""
+
+	var servers []locatedServer
+	if serverSelected {
+		server, serverIndex, err := ServerFromOAS(doc, opts)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, locatedServer{server, []string{"servers", strconv.Itoa(max(serverIndex, 0))}})
+	} else {
+		for idx, server := range doc.Servers {
+			servers = append(servers, locatedServer{server, []string{"servers", strconv.Itoa(idx)}})
+		}
+	}
+
+	// invalid root extensions are reported by the generators
+	rootExtension, _ := NewKuadrantOASRootExtension(doc)
+
+	for _, oasOperation := range OperationsFromOAS(doc, opts) {
+		if operationDisabled(rootExtension, oasOperation) {
+			continue
+		}
+
+		operationServers := operationServers(oasOperation)
+		if serverSelected && len(operationServers) > 0 {
+			operationServers = operationServers[:1]
+		}
+		servers = append(servers, operationServers...)
+	}
+
+	if err := validateServerVariables(doc, servers, opts); err != nil {
+		return nil, err
+	}
+
+	var problems Problems
+	hostnames := make([]gatewayapiv1.Hostname, 0)
+
+	for _, server := range servers {
+		serverURLs, err := expandServerURL(server.server, opts.GetServerVariables())
+		if err != nil {
+			problems.Add(NewError(server.pointer("url"), "invalid server url: %v", err))
+			continue
+		}
+
+		for _, serverURL := range serverURLs {
+			host := serverURL.Hostname()
+			if host == "" {
+				continue
+			}
+
+			if net.ParseIP(host) != nil {
+				opts.Warn(NewWarning(server.pointer("url"), "server host %q is an IP address, not allowed as route hostname", host))
+				continue
+			}
+
+			hostname := gatewayapiv1.Hostname(host)
+			if !slices.Contains(hostnames, hostname) {
+				hostnames = append(hostnames, hostname)
+			}
+		}
+	}
+
+	if err := problems.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
+	if len(hostnames) == 0 {
+		return nil, nil
+	}
+
+	return hostnames, nil
+}
+
+// operationDisabled returns true when the kuadrant extensions disable the operation.
+// Invalid extensions are reported by the generators.
+func operationDisabled(rootExtension *KuadrantOASRootExtension, oasOperation OASOperation) bool {
+	kuadrantExtension, err := NewKuadrantOASEffectiveExtension(rootExtension, oasOperation)
+	if err != nil {
+		return false
+	}
+
+	return kuadrantExtension.IsDisabled()
+}
+
+// RenderOpenAPIServerURLStrWithVariables renders the server URL with the values of the variables.
+// Variables without value are rendered with their default value.
+func RenderOpenAPIServerURLStrWithVariables(server *openapi3.Server, variables map[string]string) (string, error) {
+	if server == nil {
+		return "", nil
+	}
+
+	values := make(map[string]string, len(server.Variables))
+	for variableName, variable := range server.Variables {
+		values[variableName] = variable.Default
+		if value, ok := variables[variableName]; ok {
+			values[variableName] = value
+		}
+	}
+
+	return renderServerURLTemplate(server.URL, values)
+}
+
+// renderServerURLTemplate replaces the {variable} expressions of the server URL template
+func renderServerURLTemplate(urlTemplate string, values map[string]string) (string, error) {
+	var renderErr error
+	rendered := TemplateRegexp.ReplaceAllStringFunc(urlTemplate, func(expression string) string {
+		variableName := TemplateRegexp.FindStringSubmatch(expression)[1]
+		value, ok := values[variableName]
+		if !ok {
+			renderErr = fmt.Errorf("server variable %q not defined", variableName)
+		}
+		return value
+	})
+
+	return rendered, renderErr
+}
+
+// expandServerURL renders the server URL once per combination of the enum values of the variables
+// not set by the user
+func expandServerURL(server *openapi3.Server, variables map[string]string) ([]*url.URL, error) {
+	combinations := []map[string]string{{}}
+
+	variableNames := make([]string, 0, len(server.Variables))
+	for variableName := range server.Variables {
+		variableNames = append(variableNames, variableName)
+	}
+	sort.Strings(variableNames)
+
+	for _, variableName := range variableNames {
+		variable := server.Variables[variableName]
+
+		values := []string{variable.Default}
+		if value, ok := variables[variableName]; ok {
+			values = []string{value}
+		} else if len(variable.Enum) > 0 {
+			values = variable.Enum
+		}
+
+		expanded := make([]map[string]string, 0, len(combinations)*len(values))
+		for _, combination := range combinations {
+			for _, value := range values {
+				next := make(map[string]string, len(combination)+1)
+				for k, v := range combination {
+					next[k] = v
+				}
+				next[variableName] = value
+				expanded = append(expanded, next)
+			}
+		}
+		combinations = expanded
+	}
+
+	serverURLs := make([]*url.URL, 0, len(combinations))
+	for _, combination := range combinations {
+		serverURLStr, err := renderServerURLTemplate(server.URL, combination)
+		if err != nil {
+			return nil, err
+		}
+
+		serverURL, err := url.Parse(serverURLStr)
+		if err != nil {
+			return nil, err
+		}
+
+		serverURLs = append(serverURLs, serverURL)
+	}
+
+	return serverURLs, nil
+}
+
+// ValidateServerVariables checks the variables of the options are defined by the servers the base paths
+// are read from, and are allowed by their enums. To be called once per document, before reading the base paths
+// of the operations.
+func ValidateServerVariables(doc *openapi3.T, opts *GenerateOptions) error {
+	return validateServerVariables(doc, serversInUse(doc, opts), opts)
+}
+
+// validateServerVariables checks the variables of the options are defined by the servers in use,
+// or the given servers, and their values are allowed by the variable enum of the given servers
+func validateServerVariables(doc *openapi3.T, servers []locatedServer, opts *GenerateOptions) error {
+	variables := opts.GetServerVariables()
+	if len(variables) == 0 {
+		return nil
+	}
+
+	var problems Problems
+
+	allServers := append(serversInUse(doc, opts), servers...)
+	for _, variableName := range SortedKeys(variables) {
+		if !slices.ContainsFunc(allServers, func(server locatedServer) bool {
+			_, ok := server.server.Variables[variableName]
+			return ok
+		}) {
+			problems.Add(NewError(JSONPointer("servers"), "server variable %q not defined", variableName))
+			continue
+		}
+
+		value := variables[variableName]
+		for _, server := range servers {
+			variable, ok := server.server.Variables[variableName]
+			if ok && len(variable.Enum) > 0 && !slices.Contains(variable.Enum, value) {
+				problems.Add(NewError(
+					server.pointer("variables", variableName, "enum"),
+					"server variable %q value %q not allowed, valid values: %v", variableName, value, variable.Enum,
+				))
+			}
+		}
+	}
+
+	return problems.ErrorOrNil()
+}
+
+// ParseServerVariables parses the name=value server variable assignments
+func ParseServerVariables(assignments []string) (map[string]string, error) {
+	if len(assignments) == 0 {
+		return nil, nil
+	}
+
+	variables := make(map[string]string, len(assignments))
+	for _, assignment := range assignments {
+		name, value, ok := strings.Cut(assignment, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid server variable %q, expected name=value", assignment)
+		}
+		variables[name] = value
+	}
+
+	return variables, nil
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("OpenAPI servers", func() {
+	doc := &openapi3.T{
+		Servers: openapi3.Servers{
+			{URL: "/relative"},
+			{URL: "http://10.0.0.1:8080/ip"},
+			{
+				URL: "https://{env}.example.com/{basePath}",
+				Variables: map[string]*openapi3.ServerVariable{
+					"env":      {Default: "prod", Enum: []string{"prod", "staging"}},
+					"basePath": {Default: "api"},
+				},
+			},
+		},
+	}
+
+	It("base path of the first server by default", func() {
+		basePath, err := BasePathFromOAS(doc, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(basePath).To(Equal("/relative"))
+	})
+
+	It("base path rendered with the server variables", func() {
+		opts := &GenerateOptions{ServerIndex: &[]int{2}[0], ServerVariables: map[string]string{"basePath": "v2"}}
+		basePath, err := BasePathFromOAS(doc, opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(basePath).To(Equal("/v2"))
+	})
+
+	It("default server when none declared", func() {
+		basePath, err := BasePathFromOAS(&openapi3.T{}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(basePath).To(Equal("/"))
+	})
+
+	It("hostnames skip relative URLs and IP addresses", func() {
+		var warnings Problems
+		opts := &GenerateOptions{WarningHandler: func(problem Problem) { warnings.Add(problem) }}
+
+		hostnames, err := HostnamesFromOASServers(doc, opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hostnames).To(HaveExactElements(
+			gatewayapiv1.Hostname("prod.example.com"),
+			gatewayapiv1.Hostname("staging.example.com"),
+		))
+		Expect(warnings).To(HaveExactElements(NewWarning(
+			"#/servers/1/url", `server host "10.0.0.1" is an IP address, not allowed as route hostname`,
+		)))
+	})
+
+	Context("path and operation level servers", func() {
+		pathItem := &openapi3.PathItem{
+			Servers: openapi3.Servers{{URL: "https://admin.example.com/admin"}},
+			Get:     &openapi3.Operation{},
+			Delete:  &openapi3.Operation{Servers: &openapi3.Servers{{URL: "/internal/"}}},
+		}
+
+		It("path level servers override the document servers", func() {
+			basePath, err := OperationBasePathFromOAS(doc, OASOperation{"/stats", pathItem, "GET", pathItem.Get}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(basePath).To(Equal("/admin"))
+		})
+
+		It("operation level servers override the path level servers", func() {
+			basePath, err := OperationBasePathFromOAS(doc, OASOperation{"/stats", pathItem, "DELETE", pathItem.Delete}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(basePath).To(Equal("/internal/"))
+		})
+
+		It("document servers without overrides", func() {
+			operation := &openapi3.Operation{}
+			basePath, err := OperationBasePathFromOAS(doc, OASOperation{"/cat", &openapi3.PathItem{Get: operation}, "GET", operation}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(basePath).To(Equal("/relative"))
+		})
+
+		It("invalid server url located at the operation", func() {
+			operation := &openapi3.Operation{Servers: &openapi3.Servers{{URL: "https://{host}/api"}}}
+			_, err := OperationBasePathFromOAS(doc, OASOperation{"/cat", &openapi3.PathItem{Get: operation}, "GET", operation}, nil)
+			Expect(err).To(MatchError(ContainSubstring("#/paths/~1cat/get/servers/0/url: invalid server url")))
+		})
+	})
+
+	DescribeTable("ParseServerVariables",
+		func(assignments []string, expected map[string]string, expectedErr string) {
+			variables, err := ParseServerVariables(assignments)
+			if expectedErr != "" {
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(variables).To(Equal(expected))
+		},
+		Entry("none", nil, nil, ""),
+		Entry("values", []string{"env=staging", "url=a=b"}, map[string]string{"env": "staging", "url": "a=b"}, ""),
+		Entry("missing value", []string{"env"}, nil, `invalid server variable "env"`),
+		Entry("missing name", []string{"=staging"}, nil, `invalid server variable "=staging"`),
+	)
+})
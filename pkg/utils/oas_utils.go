@@ -1,11 +1,10 @@
 package utils
 
 import (
-	"bytes"
 	"fmt"
-	"html/template"
-	"net/url"
 	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
@@ -20,164 +19,161 @@ var (
 	LastSlashRegexp = regexp.MustCompile(`/$`)
 )
 
-func FirstServerFromOpenAPI(obj *openapi3.T) *openapi3.Server {
-	if obj == nil {
-		return nil
-	}
-
-	// take only first server
-	// From https://github.com/OAI/OpenAPI-Specification/blob/master/versions/3.0.3.md
-	//   If the servers property is not provided, or is an empty array, the default value would be a Server Object with a url value of /.
-	server := &openapi3.Server{
-		URL:       `/`,
-		Variables: map[string]*openapi3.ServerVariable{},
-	}
-
-	// Current constraint: only read the first item when there are multiple servers
-	// Maybe this should be user provided setting
-	if len(obj.Servers) > 0 {
-		server = obj.Servers[0]
-	}
+// OpenAPIMatcherFromOASOperations returns the route match of the operation:
+// the method, the path and the header and query parameters
+func OpenAPIMatcherFromOASOperations(basePath, path string, pathItem *openapi3.PathItem, verb string, op *openapi3.Operation, pathMatchType gatewayapiv1.PathMatchType, opts *GenerateOptions) (gatewayapiv1.HTTPRouteMatch, error) {
+	parameters := operationLocatedParameters(path, verb, pathItem, op)
+	pathMatch := pathMatchFromOAS(basePath, path, parameters, pathMatchType, opts)
 
-	return server
-}
+	var problems Problems
 
-func RenderOpenAPIServerURLStr(server *openapi3.Server) (string, error) {
-	if server == nil {
-		return "", nil
-	}
+	var headersMatch []gatewayapiv1.HTTPHeaderMatch
+	var queryParams []gatewayapiv1.HTTPQueryParamMatch
 
-	data := &struct {
-		Data map[string]string
-	}{
-		map[string]string{},
-	}
+	for _, parameter := range parameters {
+		if parameter.Value.In != openapi3.ParameterInHeader && parameter.Value.In != openapi3.ParameterInQuery {
+			continue
+		}
 
-	for variableName, variable := range server.Variables {
-		data.Data[variableName] = variable.Default
-	}
+		matchType, matchValue, ok, err := parameterMatchFromOAS(parameter, opts)
+		if err != nil {
+			problems.Append(err)
+			continue
+		}
 
-	urlTemplate := TemplateRegexp.ReplaceAllString(server.URL, `{{ index .Data "$1" }}`)
+		if !ok {
+			continue
+		}
 
-	tObj, err := template.New(server.URL).Parse(urlTemplate)
-	if err != nil {
-		return "", err
+		if parameter.Value.In == openapi3.ParameterInHeader {
+			headersMatch = append(headersMatch, gatewayapiv1.HTTPHeaderMatch{
+				Type:  &matchType,
+				Name:  gatewayapiv1.HTTPHeaderName(parameter.Value.Name),
+				Value: matchValue,
+			})
+		} else {
+			queryParams = append(queryParams, gatewayapiv1.HTTPQueryParamMatch{
+				Type:  &[]gatewayapiv1.QueryParamMatchType{gatewayapiv1.QueryParamMatchType(matchType)}[0],
+				Name:  gatewayapiv1.HTTPHeaderName(parameter.Value.Name),
+				Value: matchValue,
+			})
+		}
 	}
 
-	var tpl bytes.Buffer
-	err = tObj.Execute(&tpl, data)
-	if err != nil {
-		return "", err
+	if err := problems.ErrorOrNil(); err != nil {
+		return gatewayapiv1.HTTPRouteMatch{}, err
 	}
 
-	return tpl.String(), nil
+	return gatewayapiv1.HTTPRouteMatch{
+		Method:      &[]gatewayapiv1.HTTPMethod{gatewayapiv1.HTTPMethod(verb)}[0],
+		Path:        &pathMatch,
+		Headers:     headersMatch,
+		QueryParams: queryParams,
+	}, nil
 }
 
-func RenderOpenAPIServerURL(server *openapi3.Server) (*url.URL, error) {
-	serverURLStr, err := RenderOpenAPIServerURLStr(server)
-	if err != nil {
-		return nil, err
-	}
-
-	serverURL, err := url.Parse(serverURLStr)
-	if err != nil {
-		return nil, err
-	}
-
-	return serverURL, nil
+// locatedParameter is a parameter along with the JSON pointer reference tokens locating it in the document
+type locatedParameter struct {
+	*openapi3.ParameterRef
+	tokens []string
 }
 
-func BasePathFromOpenAPI(obj *openapi3.T) (string, error) {
-	server := FirstServerFromOpenAPI(obj)
-	serverURL, err := RenderOpenAPIServerURL(server)
-	if err != nil {
-		return "", err
-	}
-
-	return serverURL.Path, nil
+func (l locatedParameter) pointer(tokens ...string) string {
+	return JSONPointer(append(append([]string{}, l.tokens...), tokens...)...)
 }
 
-func OpenAPIMatcherFromOASOperations(basePath, path string, pathItem *openapi3.PathItem, verb string, op *openapi3.Operation, pathMatchType gatewayapiv1.PathMatchType) gatewayapiv1.HTTPRouteMatch {
-	// remove the last slash of the Base Path
-	sanitizedBasePath := LastSlashRegexp.ReplaceAllString(basePath, "")
-
-	//  According OAS 3.0: path MUST begin with a slash
-	matchPath := fmt.Sprintf("%s%s", sanitizedBasePath, path)
-
-	pathHeadersMatch := headersMatchFromParams(pathItem.Parameters)
-	operationHeadersMatch := headersMatchFromParams(op.Parameters)
-
-	// default headersMatch at the path level
-	headersMatch := pathHeadersMatch
-	if len(operationHeadersMatch) > 0 {
-		headersMatch = operationHeadersMatch
-	}
-
-	pathQueryParamsMatch := queryParamsMatchFromParams(pathItem.Parameters)
-	operationQueryParamsMatch := queryParamsMatchFromParams(op.Parameters)
-
-	// default queryParams at the path level
-	queryParams := pathQueryParamsMatch
-	if len(operationQueryParamsMatch) > 0 {
-		queryParams = operationQueryParamsMatch
-	}
-
-	return gatewayapiv1.HTTPRouteMatch{
-		Method: &[]gatewayapiv1.HTTPMethod{gatewayapiv1.HTTPMethod(verb)}[0],
-		Path: &gatewayapiv1.HTTPPathMatch{
-			Type:  &pathMatchType,
-			Value: &[]string{matchPath}[0],
-		},
-		Headers:     headersMatch,
-		QueryParams: queryParams,
+// findLocatedParameter returns the parameter of the location and name, nil if not found
+func findLocatedParameter(parameters []locatedParameter, in, name string) *locatedParameter {
+	for idx := range parameters {
+		if parameters[idx].Value.In == in && parameters[idx].Value.Name == name {
+			return &parameters[idx]
+		}
 	}
+	return nil
 }
 
-func headersMatchFromParams(params openapi3.Parameters) []gatewayapiv1.HTTPHeaderMatch {
-	matches := make([]gatewayapiv1.HTTPHeaderMatch, 0)
+// operationLocatedParameters returns the parameters defined at the path level and not overridden by the operation,
+// followed by the parameters of the operation
+func operationLocatedParameters(path, verb string, pathItem *openapi3.PathItem, op *openapi3.Operation) []locatedParameter {
+	parameters := make([]locatedParameter, 0, len(pathItem.Parameters)+len(op.Parameters))
 
-	for _, parameter := range params {
-		if !parameter.Value.Required {
+	for idx, parameterRef := range pathItem.Parameters {
+		if parameterRef.Value == nil || op.Parameters.GetByInAndName(parameterRef.Value.In, parameterRef.Value.Name) != nil {
 			continue
 		}
-
-		if parameter.Value.In == openapi3.ParameterInHeader {
-			matches = append(matches, gatewayapiv1.HTTPHeaderMatch{
-				Type: &[]gatewayapiv1.HeaderMatchType{gatewayapiv1.HeaderMatchExact}[0],
-				Name: gatewayapiv1.HTTPHeaderName(parameter.Value.Name),
-			})
-		}
+		parameters = append(parameters, locatedParameter{parameterRef, []string{"paths", path, "parameters", strconv.Itoa(idx)}})
 	}
 
-	if len(matches) == 0 {
-		return nil
+	for idx, parameterRef := range op.Parameters {
+		if parameterRef.Value == nil {
+			continue
+		}
+		parameters = append(parameters, locatedParameter{parameterRef, []string{"paths", path, strings.ToLower(verb), "parameters", strconv.Itoa(idx)}})
 	}
 
-	return matches
+	return parameters
 }
 
-func queryParamsMatchFromParams(params openapi3.Parameters) []gatewayapiv1.HTTPQueryParamMatch {
-	matches := make([]gatewayapiv1.HTTPQueryParamMatch, 0)
-
-	for _, parameter := range params {
-		if !parameter.Value.Required {
-			continue
-		}
-
-		if parameter.Value.In == openapi3.ParameterInQuery {
-			matches = append(matches, gatewayapiv1.HTTPQueryParamMatch{
-				Type: &[]gatewayapiv1.QueryParamMatchType{gatewayapiv1.QueryParamMatchExact}[0],
-				Name: gatewayapiv1.HTTPHeaderName(parameter.Value.Name),
-			})
+// parameterMatchFromOAS returns the match type and value of a header or query parameter.
+// The kuadrant extension of the parameter forces or suppresses the match.
+// Otherwise, required parameters are matched with the value derived from their schema:
+// a single enum value (or const) is matched exactly, several enum values or a pattern with a regular expression
+// fully matching the values, the pattern matching the values containing a match unless anchored.
+// Required parameters without such a schema are not matched, reported as warnings.
+func parameterMatchFromOAS(parameter locatedParameter, opts *GenerateOptions) (gatewayapiv1.HeaderMatchType, string, bool, error) {
+	kuadrantParameterExtension, err := NewKuadrantOASParameterExtension(parameter.Value)
+	if err != nil {
+		return "", "", false, NewError(
+			JSONPointer(append(parameter.tokens, KuadrantExtensionKey)...),
+			"invalid openapi parameter kuadrant extension: %v", err,
+		)
+	}
+
+	if kuadrantParameterExtension.IsDisabled() {
+		return "", "", false, nil
+	}
+
+	if match := kuadrantParameterExtension.Match; match != nil {
+		return match.GetType(), match.Value, true, nil
+	}
+
+	if !parameter.Value.Required {
+		return "", "", false, nil
+	}
+
+	if parameter.Value.Schema != nil && parameter.Value.Schema.Value != nil {
+		schema := parameter.Value.Schema.Value
+
+		switch {
+		case len(schema.Enum) == 1:
+			return gatewayapiv1.HeaderMatchExact, fmt.Sprintf("%v", schema.Enum[0]), true, nil
+		case len(schema.Enum) > 1:
+			values := make([]string, 0, len(schema.Enum))
+			for _, value := range schema.Enum {
+				values = append(values, regexp.QuoteMeta(fmt.Sprintf("%v", value)))
+			}
+			return gatewayapiv1.HeaderMatchRegularExpression, "^(?:" + strings.Join(values, "|") + ")$", true, nil
+		case schema.Pattern != "":
+			regex, err := valuePatternRegex(schema.Pattern)
+			if err != nil {
+				opts.Warn(NewWarning(
+					parameter.pointer("schema", "pattern"),
+					"required %s parameter %q not matched, pattern %q not supported: %v",
+					parameter.Value.In, parameter.Value.Name, schema.Pattern, err,
+				))
+				return "", "", false, nil
+			}
+			return gatewayapiv1.HeaderMatchRegularExpression, regex, true, nil
 		}
 	}
 
-	if len(matches) == 0 {
-		return nil
-	}
-
-	return matches
+	opts.Warn(NewWarning(
+		JSONPointer(parameter.tokens...),
+		"required %s parameter %q not matched, the value cannot be derived from the schema: set an enum or a pattern, or the kuadrant extension match",
+		parameter.Value.In, parameter.Value.Name,
+	))
 
+	return "", "", false, nil
 }
 
 func OpenAPIOperationName(path, opVerb string, op *openapi3.Operation) string {
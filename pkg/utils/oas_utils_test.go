@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("OpenAPI parameter matches", func() {
+	headerParameter := func(required bool, schema *openapi3.Schema, extension any) *openapi3.ParameterRef {
+		parameter := &openapi3.Parameter{Name: "X-Pet-Kind", In: openapi3.ParameterInHeader, Required: required}
+		if schema != nil {
+			parameter.Schema = schema.NewRef()
+		}
+		if extension != nil {
+			parameter.Extensions = map[string]any{KuadrantExtensionKey: extension}
+		}
+		return &openapi3.ParameterRef{Value: parameter}
+	}
+
+	match := func(parameters openapi3.Parameters, opts *GenerateOptions) (gatewayapiv1.HTTPRouteMatch, error) {
+		op := &openapi3.Operation{Parameters: parameters}
+		return OpenAPIMatcherFromOASOperations("/", "/pets", &openapi3.PathItem{Get: op}, "GET", op, gatewayapiv1.PathMatchExact, opts)
+	}
+
+	DescribeTable("header match derived from the parameter",
+		func(parameter *openapi3.ParameterRef, expected []gatewayapiv1.HTTPHeaderMatch) {
+			routeMatch, err := match(openapi3.Parameters{parameter}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(routeMatch.Headers).To(Equal(expected))
+		},
+		Entry("single enum value", headerParameter(true, openapi3.NewStringSchema().WithEnum("dog"), nil),
+			[]gatewayapiv1.HTTPHeaderMatch{{Type: ptr.To(gatewayapiv1.HeaderMatchExact), Name: "X-Pet-Kind", Value: "dog"}}),
+		Entry("several enum values", headerParameter(true, openapi3.NewStringSchema().WithEnum("dog", "cat.v2"), nil),
+			[]gatewayapiv1.HTTPHeaderMatch{{Type: ptr.To(gatewayapiv1.HeaderMatchRegularExpression), Name: "X-Pet-Kind", Value: `^(?:dog|cat\.v2)$`}}),
+		Entry("anchored pattern", headerParameter(true, openapi3.NewStringSchema().WithPattern(`^[a-z]+$`), nil),
+			[]gatewayapiv1.HTTPHeaderMatch{{Type: ptr.To(gatewayapiv1.HeaderMatchRegularExpression), Name: "X-Pet-Kind", Value: `^(?:[a-z]+)$`}}),
+		Entry("unanchored pattern", headerParameter(true, openapi3.NewStringSchema().WithPattern(`dog|cat`), nil),
+			[]gatewayapiv1.HTTPHeaderMatch{{Type: ptr.To(gatewayapiv1.HeaderMatchRegularExpression), Name: "X-Pet-Kind", Value: `^.*(?:dog|cat).*$`}}),
+		Entry("pattern anchored at the start only", headerParameter(true, openapi3.NewStringSchema().WithPattern(`^dog`), nil),
+			[]gatewayapiv1.HTTPHeaderMatch{{Type: ptr.To(gatewayapiv1.HeaderMatchRegularExpression), Name: "X-Pet-Kind", Value: `^(?:dog).*$`}}),
+		Entry("not required", headerParameter(false, openapi3.NewStringSchema().WithEnum("dog"), nil),
+			nil),
+		Entry("match forced by the kuadrant extension", headerParameter(false, nil, map[string]any{"match": map[string]any{"value": "bird"}}),
+			[]gatewayapiv1.HTTPHeaderMatch{{Type: ptr.To(gatewayapiv1.HeaderMatchExact), Name: "X-Pet-Kind", Value: "bird"}}),
+		Entry("match type set by the kuadrant extension", headerParameter(true, openapi3.NewStringSchema().WithEnum("dog"), map[string]any{"match": map[string]any{"type": "RegularExpression", "value": "^b"}}),
+			[]gatewayapiv1.HTTPHeaderMatch{{Type: ptr.To(gatewayapiv1.HeaderMatchRegularExpression), Name: "X-Pet-Kind", Value: "^b"}}),
+		Entry("match suppressed by the kuadrant extension", headerParameter(true, openapi3.NewStringSchema().WithEnum("dog"), map[string]any{"disable": true}),
+			nil),
+	)
+
+	It("query parameters matched like headers", func() {
+		parameter := &openapi3.Parameter{Name: "kind", In: openapi3.ParameterInQuery, Required: true, Schema: openapi3.NewStringSchema().WithEnum("dog").NewRef()}
+		routeMatch, err := match(openapi3.Parameters{{Value: parameter}}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(routeMatch.QueryParams).To(Equal([]gatewayapiv1.HTTPQueryParamMatch{
+			{Type: ptr.To(gatewayapiv1.QueryParamMatchExact), Name: "kind", Value: "dog"},
+		}))
+	})
+
+	It("required parameter without value skipped with a warning", func() {
+		var warnings Problems
+		opts := &GenerateOptions{WarningHandler: func(problem Problem) { warnings.Add(problem) }}
+
+		routeMatch, err := match(openapi3.Parameters{headerParameter(true, openapi3.NewStringSchema(), nil)}, opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(routeMatch.Headers).To(BeEmpty())
+		Expect(warnings).To(HaveExactElements(NewWarning(
+			"#/paths/~1pets/get/parameters/0",
+			`required header parameter "X-Pet-Kind" not matched, the value cannot be derived from the schema: set an enum or a pattern, or the kuadrant extension match`,
+		)))
+	})
+
+	It("required parameter with an unsupported pattern skipped with a warning", func() {
+		var warnings Problems
+		opts := &GenerateOptions{WarningHandler: func(problem Problem) { warnings.Add(problem) }}
+
+		routeMatch, err := match(openapi3.Parameters{headerParameter(true, openapi3.NewStringSchema().WithPattern(`^dog$|^cat$`), nil)}, opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(routeMatch.Headers).To(BeEmpty())
+		Expect(warnings).To(HaveExactElements(NewWarning(
+			"#/paths/~1pets/get/parameters/0/schema/pattern",
+			`required header parameter "X-Pet-Kind" not matched, pattern "^dog$|^cat$" not supported: anchors only supported at the start and the end of the pattern`,
+		)))
+	})
+
+	It("invalid kuadrant extension reported", func() {
+		_, err := match(openapi3.Parameters{headerParameter(true, nil, map[string]any{"disable": "yes"})}, nil)
+		Expect(err).To(MatchError(ContainSubstring("#/paths/~1pets/get/parameters/0/x-kuadrant: invalid openapi parameter kuadrant extension")))
+	})
+
+	It("operation parameters override the path parameters", func() {
+		pathParameter := headerParameter(true, openapi3.NewStringSchema().WithEnum("cat"), nil)
+		op := &openapi3.Operation{Parameters: openapi3.Parameters{headerParameter(true, openapi3.NewStringSchema().WithEnum("dog"), nil)}}
+		pathItem := &openapi3.PathItem{Parameters: openapi3.Parameters{pathParameter}, Get: op}
+
+		routeMatch, err := OpenAPIMatcherFromOASOperations("/", "/pets", pathItem, "GET", op, gatewayapiv1.PathMatchExact, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(routeMatch.Headers).To(HaveExactElements(
+			gatewayapiv1.HTTPHeaderMatch{Type: ptr.To(gatewayapiv1.HeaderMatchExact), Name: "X-Pet-Kind", Value: "dog"},
+		))
+	})
+})
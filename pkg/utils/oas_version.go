@@ -0,0 +1,249 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"sigs.k8s.io/yaml"
+)
+
+// OpenAPI specification major.minor versions
+const (
+	// OpenAPIVersion20 stands for Swagger 2.0
+	OpenAPIVersion20 = "2.0"
+	OpenAPIVersion30 = "3.0"
+	OpenAPIVersion31 = "3.1"
+)
+
+// OpenAPIVersionFromData returns the major.minor version of the OpenAPI specification the document adheres to,
+// as declared by the openapi field, or the swagger field for Swagger 2.0 documents. Example: 3.1
+// Empty when the field is missing, left to the OpenAPI validation.
+func OpenAPIVersionFromData(data []byte) (string, error) {
+	var header struct {
+		OpenAPI string `json:"openapi"`
+		Swagger string `json:"swagger"`
+	}
+
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return "", err
+	}
+
+	if header.OpenAPI == "" && header.Swagger != "" {
+		if header.Swagger != OpenAPIVersion20 {
+			return "", fmt.Errorf("unsupported Swagger version %q, supported versions: 2.0", header.Swagger)
+		}
+		return OpenAPIVersion20, nil
+	}
+
+	if header.OpenAPI == "" {
+		return "", nil
+	}
+
+	for _, version := range []string{OpenAPIVersion30, OpenAPIVersion31} {
+		if header.OpenAPI == version || strings.HasPrefix(header.OpenAPI, version+".") {
+			return version, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported OpenAPI version %q, supported versions: 3.0.x, 3.1.x", header.OpenAPI)
+}
+
+// Swagger2ToOpenAPI3 converts a Swagger 2.0 document (JSON or YAML) to OpenAPI 3.0, as JSON.
+// Vendor extensions, like the kuadrant extensions, are kept at the root, path and operation levels.
+// The host, basePath and schemes fields are converted to servers, one per scheme, and the
+// securityDefinitions to security schemes: basic becomes http basic, apiKey and oauth2 are kept.
+func Swagger2ToOpenAPI3(data []byte) ([]byte, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc2 openapi2.T
+	if err := json.Unmarshal(jsonData, &doc2); err != nil {
+		return nil, err
+	}
+
+	doc3, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc3.Paths == nil {
+		doc3.Paths = openapi3.Paths{}
+	}
+
+	// The base path is only converted along with the host
+	if doc2.Host == "" && doc2.BasePath != "" {
+		doc3.AddServer(&openapi3.Server{URL: doc2.BasePath})
+	}
+
+	return doc3.MarshalJSON()
+}
+
+// OpenAPI31To30 rewrites an OpenAPI 3.1 document (JSON or YAML) in the OpenAPI 3.0 form, as JSON.
+// Only the constructs needed to load the document are rewritten, the openapi version field is kept.
+//   - webhooks and jsonSchemaDialect are removed. Webhooks are not routed by the gateway.
+//   - info summary and license identifier are removed
+//   - path items referenced from components.pathItems are inlined
+//   - type arrays including "null" become nullable, other type arrays are removed
+//   - numeric exclusiveMinimum and exclusiveMaximum become minimum and maximum with the boolean form
+//   - const becomes a single value enum
+//   - examples arrays of schemas become example
+//   - JSON Schema 2020-12 keywords unknown to OpenAPI 3.0 are removed, arrays without items accept any item
+//
+// The kuadrant extensions are left untouched.
+func OpenAPI31To30(data []byte) ([]byte, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(jsonData, &doc); err != nil {
+		return nil, err
+	}
+
+	delete(doc, "webhooks")
+	delete(doc, "jsonSchemaDialect")
+
+	if info, ok := doc["info"].(map[string]any); ok {
+		delete(info, "summary")
+		if license, ok := info["license"].(map[string]any); ok {
+			delete(license, "identifier")
+		}
+	}
+
+	// Paths is optional in 3.1
+	paths, _ := doc["paths"].(map[string]any)
+	if paths == nil {
+		paths = map[string]any{}
+		doc["paths"] = paths
+	}
+
+	if components, ok := doc["components"].(map[string]any); ok {
+		componentPathItems, _ := components["pathItems"].(map[string]any)
+		for path, pathItem := range paths {
+			pathItemObj, ok := pathItem.(map[string]any)
+			if !ok {
+				continue
+			}
+			ref, ok := pathItemObj["$ref"].(string)
+			if !ok || !strings.HasPrefix(ref, "#/components/pathItems/") {
+				continue
+			}
+			if resolved, ok := componentPathItems[strings.TrimPrefix(ref, "#/components/pathItems/")]; ok {
+				paths[path] = resolved
+			}
+		}
+		delete(components, "pathItems")
+	}
+
+	downgradeJSONSchemas(doc, false)
+
+	return json.Marshal(doc)
+}
+
+// jsonSchemaNamesMaps are the fields holding maps keyed by user defined names,
+// like schema properties or the components. A property could be named const.
+var jsonSchemaNamesMaps = map[string]bool{
+	"properties":      true,
+	"schemas":         true,
+	"parameters":      true,
+	"responses":       true,
+	"examples":        true,
+	"requestBodies":   true,
+	"headers":         true,
+	"securitySchemes": true,
+	"links":           true,
+	"callbacks":       true,
+	"encoding":        true,
+	"content":         true,
+	"variables":       true,
+}
+
+// jsonSchema2020Keywords are the JSON Schema 2020-12 keywords without OpenAPI 3.0 counterpart
+var jsonSchema2020Keywords = []string{
+	"$schema", "$id", "$anchor", "$comment", "$defs", "$dynamicRef", "$dynamicAnchor",
+	"prefixItems", "contains", "minContains", "maxContains", "unevaluatedItems",
+	"patternProperties", "propertyNames", "unevaluatedProperties", "dependentSchemas", "dependentRequired",
+	"if", "then", "else", "contentEncoding", "contentMediaType", "contentSchema",
+}
+
+// downgradeJSONSchemas walks the document rewriting JSON Schema 2020-12 keywords
+// in their OpenAPI 3.0 schema object form.
+// The rewritten keywords (or their rewritten value types) are only found in schemas.
+// Maps keyed by user defined names are not rewritten.
+func downgradeJSONSchemas(value any, namesMap bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		if namesMap {
+			for _, item := range v {
+				downgradeJSONSchemas(item, false)
+			}
+			return
+		}
+
+		if types, ok := v["type"].([]any); ok {
+			var nonNullTypes []any
+			for _, t := range types {
+				if t == "null" {
+					v["nullable"] = true
+					continue
+				}
+				nonNullTypes = append(nonNullTypes, t)
+			}
+			delete(v, "type")
+			if len(nonNullTypes) == 1 {
+				v["type"] = nonNullTypes[0]
+			}
+		}
+
+		if v["type"] == "null" {
+			delete(v, "type")
+			v["nullable"] = true
+		}
+
+		for keyword, bound := range map[string]string{"exclusiveMinimum": "minimum", "exclusiveMaximum": "maximum"} {
+			if limit, ok := v[keyword].(float64); ok {
+				v[bound] = limit
+				v[keyword] = true
+			}
+		}
+
+		if constValue, ok := v["const"]; ok {
+			v["enum"] = []any{constValue}
+			delete(v, "const")
+		}
+
+		if examples, ok := v["examples"].([]any); ok {
+			if len(examples) > 0 {
+				v["example"] = examples[0]
+			}
+			delete(v, "examples")
+		}
+
+		for _, keyword := range jsonSchema2020Keywords {
+			delete(v, keyword)
+		}
+
+		// items is required for arrays in 3.0
+		if _, ok := v["items"]; v["type"] == "array" && !ok {
+			v["items"] = map[string]any{}
+		}
+
+		for key, item := range v {
+			if key == KuadrantExtensionKey {
+				continue
+			}
+			downgradeJSONSchemas(item, jsonSchemaNamesMaps[key])
+		}
+	case []any:
+		for _, item := range v {
+			downgradeJSONSchemas(item, false)
+		}
+	}
+}
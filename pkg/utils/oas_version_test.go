@@ -0,0 +1,248 @@
+package utils
+
+import (
+	"encoding/json"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = DescribeTable("OpenAPIVersionFromData",
+	func(data string, expected string, expectedErr string) {
+		version, err := OpenAPIVersionFromData([]byte(data))
+		if expectedErr != "" {
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			return
+		}
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version).To(Equal(expected))
+	},
+	Entry("3.0 yaml", `openapi: "3.0.3"`, OpenAPIVersion30, ""),
+	Entry("3.1 yaml", `openapi: 3.1.0`, OpenAPIVersion31, ""),
+	Entry("3.1 json", `{"openapi": "3.1.1"}`, OpenAPIVersion31, ""),
+	Entry("swagger 2.0", `swagger: "2.0"`, OpenAPIVersion20, ""),
+	Entry("missing version", `info: {}`, "", ""),
+	Entry("unsupported version", `openapi: "4.0.0"`, "", `unsupported OpenAPI version "4.0.0"`),
+)
+
+var _ = Describe("OpenAPI31To30", func() {
+	var (
+		oasData = []byte(`
+openapi: "3.1.0"
+info:
+  title: "Pet Store API"
+  summary: "Pets as a service"
+  version: "1.0.0"
+  license:
+    name: Apache 2.0
+    identifier: Apache-2.0
+jsonSchemaDialect: "https://spec.openapis.org/oas/3.1/dialect/base"
+paths:
+  /dog:
+    $ref: "#/components/pathItems/Dog"
+webhooks:
+  newPet:
+    post:
+      responses:
+        200:
+          description: "webhook processed"
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id:
+          type: integer
+          exclusiveMinimum: 0
+        name:
+          type: [string, "null"]
+          examples:
+            - garfield
+        const:
+          type: string
+          const: pet
+        tags:
+          type: array
+          prefixItems:
+            - type: string
+  pathItems:
+    Dog:
+      x-kuadrant:
+        rate_limit:
+          rates:
+            - limit: 1
+              duration: 10
+              unit: second
+      get:
+        operationId: "getDog"
+        parameters:
+          - name: X-Pet-Kind
+            in: header
+            required: true
+            schema:
+              type: [string, "null"]
+              const: dog
+        responses:
+          405:
+            description: "invalid input"
+`)
+	)
+
+	It("rewritten document is a valid OpenAPI 3.0 document", func() {
+		data, err := OpenAPI31To30(oasData)
+		Expect(err).ToNot(HaveOccurred())
+
+		loader := openapi3.NewLoader()
+		doc, err := loader.LoadFromData(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Validate(loader.Context)).To(Succeed())
+
+		Expect(doc.OpenAPI).To(Equal("3.1.0"))
+		Expect(doc.Extensions).ToNot(HaveKey("webhooks"))
+		Expect(doc.Extensions).ToNot(HaveKey("jsonSchemaDialect"))
+
+		pet := doc.Components.Schemas["Pet"].Value
+		Expect(pet.Properties["id"].Value.Min).To(Equal(&[]float64{0}[0]))
+		Expect(pet.Properties["id"].Value.ExclusiveMin).To(BeTrue())
+		Expect(pet.Properties["name"].Value.Type).To(Equal(openapi3.TypeString))
+		Expect(pet.Properties["name"].Value.Nullable).To(BeTrue())
+		Expect(pet.Properties["name"].Value.Example).To(Equal("garfield"))
+		Expect(pet.Properties["const"].Value.Enum).To(HaveExactElements("pet"))
+		Expect(pet.Properties["tags"].Value.Items).ToNot(BeNil())
+	})
+
+	It("path items are inlined and kuadrant extensions kept", func() {
+		data, err := OpenAPI31To30(oasData)
+		Expect(err).ToNot(HaveOccurred())
+
+		doc, err := openapi3.NewLoader().LoadFromData(data)
+		Expect(err).ToNot(HaveOccurred())
+
+		pathItem := doc.Paths["/dog"]
+		Expect(pathItem).ToNot(BeNil())
+		Expect(pathItem.Get).ToNot(BeNil())
+
+		kuadrantPathExtension, err := NewKuadrantOASPathExtension(pathItem)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(kuadrantPathExtension.RateLimit).ToNot(BeNil())
+		Expect(kuadrantPathExtension.RateLimit.Rates).To(HaveLen(1))
+
+		match, err := OpenAPIMatcherFromOASOperations("/v1", "/dog", pathItem, "GET", pathItem.Get, gatewayapiv1.PathMatchExact, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(match.Path.Value).To(Equal(&[]string{"/v1/dog"}[0]))
+		Expect(match.Headers).To(HaveLen(1))
+		Expect(match.Headers[0].Name).To(Equal(gatewayapiv1.HTTPHeaderName("X-Pet-Kind")))
+	})
+
+	It("property named like a keyword is not rewritten", func() {
+		data, err := OpenAPI31To30(oasData)
+		Expect(err).ToNot(HaveOccurred())
+
+		var doc map[string]any
+		Expect(json.Unmarshal(data, &doc)).To(Succeed())
+		properties := doc["components"].(map[string]any)["schemas"].(map[string]any)["Pet"].(map[string]any)["properties"].(map[string]any)
+		Expect(properties).To(HaveKey("const"))
+		Expect(properties).ToNot(HaveKey("enum"))
+	})
+})
+
+var _ = Describe("Swagger2ToOpenAPI3", func() {
+	var (
+		swaggerData = []byte(`
+swagger: "2.0"
+info:
+  title: "Pet Store API"
+  version: "1.0.0"
+host: example.io
+basePath: /v1
+schemes:
+  - https
+x-kuadrant:
+  route:
+    name: petstore
+paths:
+  /cat:
+    x-kuadrant:
+      pathMatchType: PathPrefix
+    get:
+      x-kuadrant:
+        disable: true
+      operationId: "getCat"
+      security:
+        - basicAuth: []
+      responses:
+        405:
+          description: "invalid input"
+securityDefinitions:
+  basicAuth:
+    type: basic
+  catApiKey:
+    type: apiKey
+    name: api_key
+    in: query
+`)
+	)
+
+	load := func(data []byte) *openapi3.T {
+		oasData, err := Swagger2ToOpenAPI3(data)
+		Expect(err).ToNot(HaveOccurred())
+
+		loader := openapi3.NewLoader()
+		doc, err := loader.LoadFromData(oasData)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Validate(loader.Context)).To(Succeed())
+		return doc
+	}
+
+	It("kuadrant extensions are kept", func() {
+		doc := load(swaggerData)
+
+		rootExtension, err := NewKuadrantOASRootExtension(doc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rootExtension.Route.Name).To(Equal(&[]string{"petstore"}[0]))
+
+		pathExtension, err := NewKuadrantOASPathExtension(doc.Paths["/cat"])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pathExtension.GetPathMatchType()).To(Equal(gatewayapiv1.PathMatchPathPrefix))
+
+		operationExtension, err := NewKuadrantOASOperationExtension(doc.Paths["/cat"].Get)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(operationExtension.Disable).To(Equal(&[]bool{true}[0]))
+	})
+
+	It("host and base path are converted to the server URL", func() {
+		doc := load(swaggerData)
+		Expect(doc.Servers).To(HaveLen(1))
+		Expect(doc.Servers[0].URL).To(Equal("https://example.io/v1"))
+
+		basePath, err := BasePathFromOAS(doc, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(basePath).To(Equal("/v1"))
+	})
+
+	It("base path without host is converted to the server URL", func() {
+		doc := load([]byte(`
+swagger: "2.0"
+info:
+  title: "Pet Store API"
+  version: "1.0.0"
+basePath: /v1
+paths: {}
+`))
+		basePath, err := BasePathFromOAS(doc, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(basePath).To(Equal("/v1"))
+	})
+
+	It("security definitions are converted to security schemes", func() {
+		doc := load(swaggerData)
+		Expect(doc.Components.SecuritySchemes).To(HaveKey("basicAuth"))
+		Expect(doc.Components.SecuritySchemes["basicAuth"].Value.Type).To(Equal("http"))
+		Expect(doc.Components.SecuritySchemes["basicAuth"].Value.Scheme).To(Equal("basic"))
+		Expect(doc.Components.SecuritySchemes).To(HaveKey("catApiKey"))
+		Expect(doc.Components.SecuritySchemes["catApiKey"].Value.Type).To(Equal("apiKey"))
+		Expect(doc.Components.SecuritySchemes["catApiKey"].Value.In).To(Equal("query"))
+	})
+})
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is an issue found in the OpenAPI document
+type Problem struct {
+	// Pointer locates the issue in the OpenAPI document.
+	// JSON pointer (RFC 6901) in URI fragment representation. Example: #/paths/~1pets/get/x-kuadrant/rate_limit
+	Pointer  string   `json:"pointer"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func NewError(pointer, format string, a ...any) Problem {
+	return Problem{Pointer: pointer, Severity: SeverityError, Message: fmt.Sprintf(format, a...)}
+}
+
+func NewWarning(pointer, format string, a ...any) Problem {
+	return Problem{Pointer: pointer, Severity: SeverityWarning, Message: fmt.Sprintf(format, a...)}
+}
+
+func (p Problem) Error() string {
+	return fmt.Sprintf("%s %s: %s", p.Severity, p.Pointer, p.Message)
+}
+
+// Problems is a list of problems found in the OpenAPI document.
+// It implements the error interface so that all the problems can be reported at once.
+type Problems []Problem
+
+func (p Problems) Error() string {
+	if len(p) == 1 {
+		return p[0].Error()
+	}
+
+	lines := make([]string, 0, len(p)+1)
+	lines = append(lines, fmt.Sprintf("%d problems found in the OpenAPI document:", len(p)))
+	for _, problem := range p {
+		lines = append(lines, fmt.Sprintf("  %s", problem.Error()))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Add appends problems not already in the list
+func (p *Problems) Add(problems ...Problem) {
+	for _, problem := range problems {
+		if !p.Contains(problem) {
+			*p = append(*p, problem)
+		}
+	}
+}
+
+// Append adds the problems carried by err.
+// Errors other than Problem or Problems are added as errors located at the document root.
+func (p *Problems) Append(err error) {
+	if err == nil {
+		return
+	}
+
+	var problems Problems
+	if errors.As(err, &problems) {
+		p.Add(problems...)
+		return
+	}
+
+	var problem Problem
+	if errors.As(err, &problem) {
+		p.Add(problem)
+		return
+	}
+
+	p.Add(NewError(JSONPointer(), "%s", err.Error()))
+}
+
+func (p Problems) HasErrors() bool {
+	for _, problem := range p {
+		if problem.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrorOrNil returns the problems as error when at least one of them has error severity
+func (p Problems) ErrorOrNil() error {
+	if !p.HasErrors() {
+		return nil
+	}
+
+	return p
+}
+
+func (p Problems) Contains(problem Problem) bool {
+	for _, existing := range p {
+		if existing == problem {
+			return true
+		}
+	}
+
+	return false
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// JSONPointer builds a JSON pointer in URI fragment representation from the reference tokens.
+// Example: JSONPointer("paths", "/pets", "get") returns #/paths/~1pets/get
+func JSONPointer(tokens ...string) string {
+	var pointer strings.Builder
+	pointer.WriteString("#")
+	for _, token := range tokens {
+		pointer.WriteString("/")
+		pointer.WriteString(jsonPointerEscaper.Replace(token))
+	}
+
+	return pointer.String()
+}
+
+// OperationJSONPointer builds a JSON pointer to an operation of the OpenAPI document followed by the tokens
+func OperationJSONPointer(path, verb string, tokens ...string) string {
+	return JSONPointer(append([]string{"paths", path, strings.ToLower(verb)}, tokens...)...)
+}
+
+// JSONPointerAppend appends the reference tokens to a JSON pointer in URI fragment representation
+// Example: JSONPointerAppend("#/paths/~1pets", "get") returns #/paths/~1pets/get
+func JSONPointerAppend(pointer string, tokens ...string) string {
+	return pointer + strings.TrimPrefix(JSONPointer(tokens...), "#")
+}
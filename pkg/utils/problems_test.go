@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("JSONPointer",
+	func(tokens []string, expected string) {
+		Expect(JSONPointer(tokens...)).To(Equal(expected))
+	},
+	Entry("document root", []string{}, "#"),
+	Entry("root extension", []string{"x-kuadrant", "route"}, "#/x-kuadrant/route"),
+	Entry("path with slashes", []string{"paths", "/pets/{petId}", "get"}, "#/paths/~1pets~1{petId}/get"),
+	Entry("token with tilde", []string{"paths", "/~pets"}, "#/paths/~1~0pets"),
+)
+
+var _ = Describe("Problems", func() {
+	It("operation pointer uses lower case method", func() {
+		Expect(OperationJSONPointer("/pets", "GET", "x-kuadrant", "rate_limit")).To(
+			Equal("#/paths/~1pets/get/x-kuadrant/rate_limit"),
+		)
+	})
+
+	It("appended tokens are escaped", func() {
+		Expect(JSONPointerAppend("#/paths/~1pets/x-kuadrant/auth", "response", "x/user")).To(
+			Equal("#/paths/~1pets/x-kuadrant/auth/response/x~1user"),
+		)
+	})
+
+	It("duplicated problems are added once", func() {
+		var problems Problems
+		problems.Add(NewError("#/x-kuadrant/route", "route not found"))
+		problems.Append(Problems{
+			NewError("#/x-kuadrant/route", "route not found"),
+			NewWarning("#/paths/~1pets", "ignored"),
+		})
+		Expect(problems).To(HaveLen(2))
+	})
+
+	It("errors without location are added at the document root", func() {
+		var problems Problems
+		problems.Append(errors.New("some error"))
+		Expect(problems).To(HaveExactElements(NewError("#", "some error")))
+	})
+
+	It("only warnings are not an error", func() {
+		var problems Problems
+		Expect(problems.ErrorOrNil()).To(BeNil())
+		problems.Add(NewWarning("#/paths/~1pets", "ignored"))
+		Expect(problems.ErrorOrNil()).To(BeNil())
+		problems.Add(NewError("#/paths/~1pets", "invalid"))
+		Expect(problems.ErrorOrNil()).To(MatchError(ContainSubstring("2 problems found")))
+	})
+})
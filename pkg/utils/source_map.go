@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SourceMap locates JSON pointers in the raw OpenAPI document (JSON or YAML)
+type SourceMap struct {
+	root *yaml.Node
+}
+
+func NewSourceMap(data []byte) (*SourceMap, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	sourceMap := &SourceMap{}
+	if len(root.Content) > 0 {
+		sourceMap.root = root.Content[0]
+	}
+
+	return sourceMap, nil
+}
+
+var jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// Line returns the line of the document where the JSON pointer (URI fragment representation) is found.
+// When the pointer cannot be fully resolved, the line of the closest existing parent is returned.
+// Zero when unknown.
+func (s *SourceMap) Line(pointer string) int {
+	if s == nil || s.root == nil {
+		return 0
+	}
+
+	node := s.root
+	line := node.Line
+
+	tokens := strings.Split(strings.TrimPrefix(strings.TrimPrefix(pointer, "#"), "/"), "/")
+	for _, token := range tokens {
+		if token == "" {
+			continue
+		}
+		token = jsonPointerUnescaper.Replace(token)
+
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for idx := 0; idx+1 < len(node.Content); idx += 2 {
+				if node.Content[idx].Value == token {
+					line = node.Content[idx].Line
+					next = node.Content[idx+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			idx, err := strconv.Atoi(token)
+			if err == nil && idx >= 0 && idx < len(node.Content) {
+				next = node.Content[idx]
+				line = next.Line
+			}
+		}
+
+		if next == nil {
+			return line
+		}
+		node = next
+	}
+
+	return line
+}